@@ -1,14 +1,20 @@
 package sudokubundle
 
 import (
+	"context"
+	stderrors "errors"
 	"net/http"
 	"sort"
+	"strconv"
 
 	"github.com/laurentlp/sudoku-solver/api/common"
 	"github.com/laurentlp/sudoku-solver/api/errors"
 	"github.com/laurentlp/sudoku-solver/solver"
 )
 
+// maxRequestSize is the maximum size of the body of a request, large enough for the variants descriptions
+const maxRequestSize = 64 << 10
+
 // SudokuController struct
 type SudokuController struct {
 	common.Controller
@@ -18,35 +24,185 @@ type SudokuController struct {
 // If there is an errors of any sort (bad sudoku, no input,...) it is sent to the client
 func (s *SudokuController) Solve(w http.ResponseWriter, r *http.Request) {
 
-	var model Sudoku
-	err := s.MapJSON(w, r, &model)
+	var model SudokuRequest
+	err := s.MapJSONLimit(w, r, &model, maxRequestSize)
 	if err == nil {
 
-		res, err := solver.Solve(model.Sudoku)
+		grid, opts, err := solverOptions(&model)
+		if err != nil {
+			apiErr := solverError(err)
+			s.SendJSON(w, r, apiErr, apiErr.Status)
+			return
+		}
+
+		// Stop solving as soon as the client goes away
+		res, stats, err := solver.SolveStats(r.Context(), grid, opts...)
 
 		if err != nil {
-			s.SendJSON(w, r, errors.BadRequest(err.Error()), http.StatusBadRequest)
+			apiErr := solverError(err)
+			s.SendJSON(w, r, apiErr, apiErr.Status)
 			return
 		}
 
 		solved := err == nil
 
 		solvedSudoku := NewSudoku(toString(res), solved)
+		if model.Stats {
+			solvedSudoku.Stats = stats
+		}
 		s.SendJSON(w, r, solvedSudoku, http.StatusOK)
 		return
 	}
 	s.SendJSON(w, r, err, err.Status)
 }
 
-// ToString convert the solved sudoku (map[string]string) to as string of values
+// Steps solve a sudoku like a human would and return the steps explaining each digit.
+// A sudoku the techniques can not solve without guessing is sent back unsolved, its unknown squares as dots.
+func (s *SudokuController) Steps(w http.ResponseWriter, r *http.Request) {
+
+	var model SudokuRequest
+	err := s.MapJSONLimit(w, r, &model, maxRequestSize)
+	if err == nil {
+
+		grid, opts, err := solverOptions(&model)
+		if err != nil {
+			apiErr := solverError(err)
+			s.SendJSON(w, r, apiErr, apiErr.Status)
+			return
+		}
+
+		if model.Uniqueness {
+			opts = append(opts, solver.WithUniqueness())
+		}
+		if model.Forcing {
+			opts = append(opts, solver.WithForcingChains())
+		}
+		res, steps, err := solver.SolveLogical(r.Context(), grid, opts...)
+
+		if err != nil && err != solver.ErrStuck {
+			apiErr := solverError(err)
+			s.SendJSON(w, r, apiErr, apiErr.Status)
+			return
+		}
+
+		// The squares whose digit is not known yet are left empty
+		for k, v := range res {
+			if len(v) > 1 {
+				res[k] = "."
+			}
+		}
+
+		s.SendJSON(w, r, NewSteps(toString(res), err == nil, steps), http.StatusOK)
+		return
+	}
+	s.SendJSON(w, r, err, err.Status)
+}
+
+// SolverOptions convert the description of the sudoku sent in the request to its grid and the solver options
+func solverOptions(model *SudokuRequest) (string, []solver.Option, error) {
+	opts := []solver.Option{solver.WithVariant(model.Variant)}
+
+	grid, cs, err := solver.ParsePuzzle(model.Sudoku)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(cs) > 0 {
+		opts = append(opts, solver.WithConstraints(cs...), solver.WithValidation(solver.LenientValidation))
+	}
+
+	switch {
+	case model.Regions != "" && model.Layout != "":
+		return "", nil, stderrors.New("A jigsaw sudoku can not be laid out on several grids")
+	case model.Regions != "":
+		g, err := solver.NewJigsawGeometry(model.Regions)
+		if err != nil {
+			return "", nil, err
+		}
+		opts = append(opts, solver.WithGeometry(g))
+	case model.Layout != "":
+		g, err := solver.LayoutGeometry(model.Layout)
+		if err != nil {
+			return "", nil, err
+		}
+		opts = append(opts, solver.WithGeometry(g))
+	}
+
+	if len(model.Cages) > 0 {
+		opts = append(opts, solver.WithConstraints(solver.Killer{Cages: model.Cages}), solver.WithValidation(solver.LenientValidation))
+	}
+
+	if len(model.Edges) > 0 || len(model.NegativeEdges) > 0 {
+		edges := solver.Edges{Edges: model.Edges, Negative: model.NegativeEdges}
+		opts = append(opts, solver.WithConstraints(edges), solver.WithValidation(solver.LenientValidation))
+	}
+
+	return grid, opts, nil
+}
+
+// SolverError map the errors returned by the solver to their api error
+func solverError(err error) *errors.APIError {
+	var (
+		sizeErr     *solver.GridSizeError
+		cluesErr    *solver.TooFewCluesError
+		charErr     *solver.InvalidCharacterError
+		conflictErr *solver.ConflictError
+		variantErr  *solver.UnknownVariantError
+		layoutErr   *solver.UnknownLayoutError
+		regionErr   *solver.RegionError
+		cageErr     *solver.CageError
+		edgeErr     *solver.EdgeError
+		clueErr     *solver.OutsideClueError
+	)
+
+	switch {
+	case stderrors.As(err, &sizeErr):
+		return errors.InvalidGridSize(err.Error(), sizeErr)
+	case stderrors.As(err, &cluesErr):
+		return errors.TooFewClues(err.Error(), cluesErr)
+	case stderrors.As(err, &charErr):
+		return errors.InvalidCharacter(err.Error(), charErr)
+	case stderrors.As(err, &conflictErr):
+		return errors.ConflictingClues(err.Error(), conflictErr)
+	case stderrors.As(err, &variantErr):
+		return errors.UnknownVariant(err.Error(), variantErr)
+	case stderrors.As(err, &layoutErr):
+		return errors.UnknownLayout(err.Error(), layoutErr)
+	case stderrors.As(err, &regionErr):
+		return errors.InvalidRegions(err.Error(), regionErr)
+	case stderrors.As(err, &cageErr):
+		return errors.InvalidCages(err.Error(), cageErr)
+	case stderrors.As(err, &edgeErr):
+		return errors.InvalidEdges(err.Error(), edgeErr)
+	case stderrors.As(err, &clueErr):
+		return errors.InvalidOutsideClues(err.Error(), clueErr)
+	case stderrors.Is(err, solver.ErrNoSolution):
+		return errors.NoSolution(err.Error())
+	case stderrors.Is(err, solver.ErrBudgetExceeded):
+		return errors.BudgetExceeded(err.Error())
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return errors.Timeout(err.Error())
+	case stderrors.Is(err, context.Canceled):
+		return errors.Cancelled(err.Error())
+	}
+	return errors.BadRequest(err.Error())
+}
+
+// ToString convert the solved sudoku (map[string]string) to as string of values, row by row
 func toString(solvedSudoku map[string]string) (res string) {
 	keys := []string{}
 	for k := range solvedSudoku {
 		keys = append(keys, k)
 	}
 
-	// Sort the map keys to make sure the output is ordered
-	sort.Strings(keys)
+	// Sort the map keys by row then by column to make sure the output is ordered, whatever the number of columns
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		ci, _ := strconv.Atoi(keys[i][1:])
+		cj, _ := strconv.Atoi(keys[j][1:])
+		return ci < cj
+	})
 	for _, k := range keys {
 		res += solvedSudoku[k]
 	}
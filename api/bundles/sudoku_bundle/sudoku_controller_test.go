@@ -1,12 +1,15 @@
 package sudokubundle_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/laurentlp/sudoku-solver/api/bundles/sudoku_bundle"
 	"github.com/laurentlp/sudoku-solver/api/errors"
@@ -49,6 +52,375 @@ func TestSudokuController(t *testing.T) {
 			})
 		})
 
+		Convey("When Solve is called from handler with a good sudoku asking for the stats", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......", "stats": true}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the stats in the JSON response", func() {
+				var model sudokubundle.Sudoku
+				err := json.NewDecoder(resp.Body).Decode(&model)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(model.Solved, ShouldBeTrue)
+				So(model.Stats, ShouldNotBeNil)
+				So(model.Stats.Nodes, ShouldBeGreaterThan, 0)
+			})
+		})
+
+		Convey("When Solve is called from handler with a diagonal sudoku", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "9...48......2.........7..4............1....9..3.1.....36...1....5..6..8....7..5..", "variant": "diagonal"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku solved with its diagonals", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, `{"sudoku":"975648213648213975213975648796584132581327496432196857369851724157462389824739561","solved":true}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a jigsaw sudoku", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{
+				"sudoku": "4......9.6..4..3...7..5...........71..8..4..9..4..3..5..3.1.2.......5.1..........",
+				"regions": "111122333111222333112222333444555666444555666447555966477888996777888999777888999"
+			}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku solved with its regions", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, `{"sudoku":"435268197681497352972351468346529871528174639194683725753916284267845913819732546","solved":true}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a jigsaw sudoku having a region split in two", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{
+				"sudoku": "4......9.6..4..3...7..5...........71..8..4..9..4..3..5..3.1.2.......5.1..........",
+				"regions": "771122333111222333112222333444555666444555666447555966477888996117888999777888999"
+			}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"INVALID_REGIONS","message":"Invalid region \"7\": its squares are not connected","details":{"region":"7","squares":9,"expected":9,"disconnected":true}}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a killer sudoku", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{
+				"sudoku": "` + strings.Repeat(".", 81) + `",
+				"cages": [{"cells":["D8","D9"],"sum":15},{"cells":["G3","H3","G4","H4"],"sum":20},{"cells":["G9","H9","G8"],"sum":12},{"cells":["E2","F2","F1"],"sum":16},{"cells":["A6","A5","B5","A7"],"sum":28},{"cells":["A3","A2","A4"],"sum":11},{"cells":["F9","E9","E8","F8"],"sum":18},{"cells":["D1","E1"],"sum":15},{"cells":["B8","B7","C8"],"sum":14},{"cells":["C1","B1","A1","B2"],"sum":22},{"cells":["G5","G6","G7"],"sum":12},{"cells":["H2","G2","I2","H1"],"sum":26},{"cells":["D6","D5","E5"],"sum":18},{"cells":["F5","F4","F3"],"sum":16},{"cells":["D7","C7","C6"],"sum":8},{"cells":["D2","C2"],"sum":7},{"cells":["I3","I4","I5","I6"],"sum":24},{"cells":["B4","B3","C3"],"sum":11},{"cells":["C9","B9","A9","A8"],"sum":20},{"cells":["I9","I8"],"sum":12},{"cells":["E6","F6"],"sum":8},{"cells":["D4","D3"],"sum":9},{"cells":["E7","F7"],"sum":11},{"cells":["E4","E3"],"sum":6},{"cells":["I1"],"sum":1},{"cells":["H7","I7","H8","H6"],"sum":17},{"cells":["B6"],"sum":8},{"cells":["C5","C4"],"sum":9},{"cells":["H5"],"sum":9},{"cells":["G1"],"sum":2}]
+			}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku solved from its cages", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, `{"sudoku":"417369825632158947958724316825437169791586432346912758289643571573291684164875293","solved":true}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a Kropki sudoku", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{
+				"sudoku": "` + strings.Repeat(".", 81) + `",
+				"edges": [{"cells":["A4","A5"],"kind":"black"},{"cells":["A5","B5"],"kind":"white"},{"cells":["A6","A7"],"kind":"white"},{"cells":["A6","B6"],"kind":"white"},{"cells":["A7","B7"],"kind":"white"},{"cells":["A8","B8"],"kind":"black"},{"cells":["B1","B2"],"kind":"black"},{"cells":["B2","B3"],"kind":"white"},{"cells":["B3","B4"],"kind":"white"},{"cells":["B6","B7"],"kind":"white"},{"cells":["B6","C6"],"kind":"black"},{"cells":["B9","C9"],"kind":"white"},{"cells":["C1","D1"],"kind":"white"},{"cells":["C3","C4"],"kind":"white"},{"cells":["C5","C6"],"kind":"black"},{"cells":["C5","D5"],"kind":"white"},{"cells":["C6","C7"],"kind":"white"},{"cells":["D1","E1"],"kind":"white"},{"cells":["D3","D4"],"kind":"white"},{"cells":["D4","D5"],"kind":"white"},{"cells":["D4","E4"],"kind":"white"},{"cells":["D6","E6"],"kind":"white"},{"cells":["D8","E8"],"kind":"black"},{"cells":["E7","E8"],"kind":"white"},{"cells":["E8","E9"],"kind":"white"},{"cells":["F1","F2"],"kind":"white"},{"cells":["F1","G1"],"kind":"white"},{"cells":["F2","G2"],"kind":"black"},{"cells":["F5","F6"],"kind":"white"},{"cells":["F6","G6"],"kind":"white"},{"cells":["G2","G3"],"kind":"white"},{"cells":["G2","H2"],"kind":"white"},{"cells":["G5","G6"],"kind":"white"},{"cells":["G7","H7"],"kind":"white"},{"cells":["G8","H8"],"kind":"white"},{"cells":["H2","I2"],"kind":"white"},{"cells":["H3","H4"],"kind":"white"},{"cells":["H3","I3"],"kind":"white"},{"cells":["H8","H9"],"kind":"black"},{"cells":["H8","I8"],"kind":"white"},{"cells":["H9","I9"],"kind":"white"},{"cells":["I3","I4"],"kind":"black"},{"cells":["I4","I5"],"kind":"white"}],
+				"negative_edges": ["white", "black"]
+			}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku solved from its dots", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, `{"sudoku":"417369825632158947958724316825437169791586432346912758289643571573291684164875293","solved":true}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a killer sudoku having an unknown square", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "` + strings.Repeat(".", 81) + `", "cages": [{"cells":["A1","Z1"],"sum":3}]}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"INVALID_CAGES","message":"Invalid cage 0: unknown square \"Z1\"","details":{"cage":0,"cell":"Z1"}}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a greater-than sign between squares which are not adjacent", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "` + strings.Repeat(".", 81) + `", "edges": [{"cells":["A1","A3"],"kind":">"}]}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"INVALID_EDGES","message":"Invalid edge 0: squares A1 and A3 are not adjacent","details":{"edge":0,"cells":["A1","A3"]}}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a sandwich sudoku", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "...3............................71...........................7...................|sandwich:L1=16,L2=13,L3=29,L4=6,L5=0,L6=0,L7=25,L8=0,L9=32,T1=25,T2=10,T3=6,T4=16,T5=4,T6=30,T7=3,T8=29,T9=10"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku solved from its sandwiches", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, `{"sudoku":"417369825632158947958724316825437169791586432346912758289643571573291684164875293","solved":true}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with an outside clue at an unknown position", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "` + strings.Repeat(".", 81) + `|skyscraper:L10=3"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"INVALID_OUTSIDE_CLUES","message":"Invalid outside clue \"skyscraper:L10=3\": unknown position \"L10\"","details":{"clue":"skyscraper:L10=3","reason":"unknown position \"L10\""}}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a Samurai", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": ".2...6..9.1......84...8.....4.6...2...9..71....8.2.........8.......5.8.7....9..12....9...6......436......4..5..8.....2.8...7......4.62......3.9...4.3..7....3........9..5.....4....94.6..7...7.......51...5.....5....1...........8....4..7..9.....9...............2....4..5.8......58..36............8...5..724...7.6....1.....42..826..3.97..7....6.....8....19....7.....4.7...3", "layout": "samurai"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the five grids solved row by row across the board", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldEqual, `{"sudoku":"123456789912345678457189263345678129689237145768129345214368957123456897365794812457893216798512436689217453532871694278531762984941623578143296584731876945321569874931562132485769456397182789612345123458967851423156789456279813924657289134789136245736918347256214365789132465897367891452549718362598724136786923415631942578261534978872513694375892641945687321894671523","solved":true}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with an unknown layout", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......", "layout": "flower"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"UNKNOWN_LAYOUT","message":"Unknown layout \"flower\": expected samurai, twodoku or butterfly","details":{"layout":"flower"}}`)
+			})
+		})
+
+		Convey("When Steps is called from handler with a sudoku solved by singles", func() {
+			mux.HandleFunc("/sudoku/steps", c.Steps)
+
+			reader := strings.NewReader(`{"sudoku": "..3.2.6..9..3.5..1..18.64....81.29..7.......8..67.82....26.95..8..2.3..9..5.1.3.."}`)
+
+			resp, err := http.Post(server.URL+"/sudoku/steps", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the solution and the steps", func() {
+				var steps struct {
+					Sudoku string
+					Solved bool
+					Steps  []map[string]interface{}
+				}
+				So(json.NewDecoder(resp.Body).Decode(&steps), ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(steps.Sudoku, ShouldEqual, "483921657967345821251876493548132976729564138136798245372689514814253769695417382")
+				So(steps.Solved, ShouldBeTrue)
+				So(steps.Steps, ShouldNotBeEmpty)
+				So(steps.Steps[0]["technique"], ShouldBeIn, "full house", "naked single", "hidden single")
+				So(steps.Steps[0]["placed"], ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When Steps is called from handler with a sudoku having a unique rectangle and uniqueness allowed", func() {
+			mux.HandleFunc("/sudoku/steps", c.Steps)
+
+			reader := strings.NewReader(`{"sudoku": "..5...987.4..5...1..7......2...48....9.1.....6..2.....3..6..2.......9.7.......5..", "uniqueness": true}`)
+
+			resp, err := http.Post(server.URL+"/sudoku/steps", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with a unique rectangle among the steps", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldContainSubstring, `"solved":true`)
+				So(string(body), ShouldContainSubstring, `"technique":"unique rectangle type 1"`)
+			})
+		})
+
+		Convey("When Steps is called from handler with a sudoku having two solutions and uniqueness allowed", func() {
+			mux.HandleFunc("/sudoku/steps", c.Steps)
+
+			reader := strings.NewReader(`{"sudoku": "53467891267219534819834256785976.42.42685.79.713924856961537284287419635345286179", "uniqueness": true}`)
+
+			resp, err := http.Post(server.URL+"/sudoku/steps", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku left unsolved", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldStartWith, `{"sudoku":"53467891267219534819834256785976.42.42685.79.713924856961537284287419635345286179","solved":false`)
+			})
+		})
+
+		Convey("When Steps is called from handler with a sudoku needing forcing chains and forcing allowed", func() {
+			mux.HandleFunc("/sudoku/steps", c.Steps)
+
+			reader := strings.NewReader(`{"sudoku": ".......4...2..4..1.7..5..9...3..7....4..6....6..1..8...2....1..85.9...6.....8...3", "forcing": true}`)
+
+			resp, err := http.Post(server.URL+"/sudoku/steps", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the branches of a forcing chain among the steps", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldContainSubstring, `"solved":true`)
+				So(string(body), ShouldContainSubstring, `"technique":"unit forcing chain"`)
+				So(string(body), ShouldContainSubstring, `"branches":[{"hypothesis":`)
+			})
+		})
+
+		Convey("When Steps is called from handler with a sudoku needing a guess", func() {
+			mux.HandleFunc("/sudoku/steps", c.Steps)
+
+			reader := strings.NewReader(`{"sudoku": "8..........36......7..9.2...5...7.......457.....1...3...1....68..85...1..9....4.."}`)
+
+			resp, err := http.Post(server.URL+"/sudoku/steps", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 200 with the sudoku left unsolved", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusOK)
+				So(string(body), ShouldStartWith, `{"sudoku":"8..........36......7..9.2...5...7.......457.....1...3...1....68..85...1..9....4..","solved":false,"steps":[`)
+			})
+		})
+
+		Convey("When Steps is called from handler with a sudoku having no solution", func() {
+			mux.HandleFunc("/sudoku/steps", c.Steps)
+
+			reader := strings.NewReader(`{"sudoku": "49....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku/steps", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 422 with the NO_SOLUTION error code", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusUnprocessableEntity)
+				So(string(body), ShouldContainSubstring, `"error_code":"NO_SOLUTION"`)
+			})
+		})
+
+		Convey("When Solve is called from handler with an unknown variant", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......", "variant": "killer-x"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "application/json", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"UNKNOWN_VARIANT","message":"Unknown variant \"killer-x\": expected classic, diagonal, windoku, anti-knight, anti-king or non-consecutive, or several of them joined with +","details":{"variant":"killer-x"}}`)
+			})
+		})
+
 		Convey("When Solve is called from handler with an short sudoku", func() {
 			mux.HandleFunc("/sudoku", c.Solve)
 
@@ -63,7 +435,88 @@ func TestSudokuController(t *testing.T) {
 				body, err := ioutil.ReadAll(resp.Body)
 				So(err, ShouldBeNil)
 				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
-				So(string(body), ShouldEqual, `{"error_code":"BAD_REQUEST","message":"Invalid grid size: expected grid size of 81 found grid size of 80"}`)
+				So(string(body), ShouldEqual, `{"error_code":"INVALID_GRID_SIZE","message":"Invalid grid size: expected grid size of 81 found grid size of 80","details":{"expected":81,"size":80}}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a sudoku having no solution", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "49....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......"}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 422 with correct JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusUnprocessableEntity)
+				So(string(body), ShouldEqual, `{"error_code":"NO_SOLUTION","message":"The sudoku has no solution"}`)
+			})
+		})
+
+		Convey("When Solve is called from handler once the deadline of the request is past", func() {
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+			reader := strings.NewReader(`{"sudoku": "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......"}`)
+			rec := httptest.NewRecorder()
+			c.Solve(rec, httptest.NewRequest(http.MethodPost, "/sudoku", reader).WithContext(ctx))
+
+			Convey("Then response should be 504 with the TIMEOUT error code", func() {
+				So(rec.Code, ShouldEqual, http.StatusGatewayTimeout)
+				So(rec.Body.String(), ShouldEqual, `{"error_code":"TIMEOUT","message":"context deadline exceeded"}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a request already cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			reader := strings.NewReader(`{"sudoku": "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......"}`)
+			rec := httptest.NewRecorder()
+			c.Solve(rec, httptest.NewRequest(http.MethodPost, "/sudoku", reader).WithContext(ctx))
+
+			Convey("Then response should be 503 with the CANCELLED error code", func() {
+				So(rec.Code, ShouldEqual, http.StatusServiceUnavailable)
+				So(rec.Body.String(), ShouldEqual, `{"error_code":"CANCELLED","message":"context canceled"}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a sudoku repeating a digit", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "..757..3.1......2.7...234......8...4..7..4...49....6.5.42...3.....7..9....18....."}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with the conflicting cells in the JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldEqual, `{"error_code":"CONFLICTING_CLUES","message":"The sudoku contains errors and can not be solved: digit 7 is given in A3 and A5","details":{"cells":["A3","A5"],"digit":"7"}}`)
+			})
+		})
+
+		Convey("When Solve is called from handler with a sudoku containing a wrong character", func() {
+			mux.HandleFunc("/sudoku", c.Solve)
+
+			reader := strings.NewReader(`{"sudoku": "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4....x."}`)
+
+			resp, err := http.Post(server.URL+"/sudoku", "", reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			Convey("Then response should be 400 with the position of the character in the JSON error", func() {
+				body, err := ioutil.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+				So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+				So(string(body), ShouldContainSubstring, `"error_code":"INVALID_CHARACTER"`)
+				So(string(body), ShouldContainSubstring, `"details":{"index":79,"char":"x"}`)
 			})
 		})
 
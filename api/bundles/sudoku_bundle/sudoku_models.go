@@ -1,9 +1,46 @@
 package sudokubundle
 
+import "github.com/laurentlp/sudoku-solver/solver"
+
 // Sudoku struct
 type Sudoku struct {
+	Sudoku string        `json:"sudoku"`
+	Solved bool          `json:"solved"`
+	Stats  *solver.Stats `json:"stats,omitempty"`
+}
+
+// Steps struct holding the sudoku solved by the logical solver and the steps taken
+type Steps struct {
+	Sudoku string        `json:"sudoku"`
+	Solved bool          `json:"solved"`
+	Steps  []solver.Step `json:"steps"`
+}
+
+// SudokuRequest struct holding the sudoku to solve and the solving options
+type SudokuRequest struct {
+	// Sudoku is the grid, optionally followed by clues outside of the grid like "<grid>|sandwich:L1=10,T3=0"
 	Sudoku string `json:"sudoku"`
-	Solved bool   `json:"solved"`
+	// Variant is the kind of sudoku to solve: classic (the default), diagonal, windoku, anti-knight, anti-king,
+	// non-consecutive or several of them joined with +
+	Variant solver.Variant `json:"variant"`
+	// Regions labels the region of each square of a jigsaw sudoku, row by row, empty for the classic boxes
+	Regions string `json:"regions"`
+	// Layout names the grids of a multi-grid sudoku: samurai, twodoku or butterfly, empty for a single grid.
+	// The sudoku then lists the squares of the grids row by row across the board, skipping the holes between them.
+	Layout string `json:"layout"`
+	// Cages are the cages of a killer sudoku, whose grid may have no clue
+	Cages []solver.Cage `json:"cages"`
+	// Edges are the dots, X, V and greater-than signs between adjacent squares
+	Edges []solver.Edge `json:"edges"`
+	// NegativeEdges lists the kinds of edges which are all given, the adjacent squares without one of them
+	// do not hold its relation
+	NegativeEdges []solver.EdgeKind `json:"negative_edges"`
+	// Stats asks for the search effort to be sent along with the solved sudoku
+	Stats bool `json:"stats"`
+	// Uniqueness lets the steps of the logical solver assume the sudoku has a single solution
+	Uniqueness bool `json:"uniqueness"`
+	// Forcing lets the steps of the logical solver make hypotheses once the other techniques are stuck
+	Forcing bool `json:"forcing"`
 }
 
 // NewSudoku create a new sudoku
@@ -13,3 +50,15 @@ func NewSudoku(sudoku string, solved bool) *Sudoku {
 		Solved: solved,
 	}
 }
+
+// NewSteps create the steps of a sudoku
+func NewSteps(sudoku string, solved bool, steps []solver.Step) *Steps {
+	if steps == nil {
+		steps = []solver.Step{}
+	}
+	return &Steps{
+		Sudoku: sudoku,
+		Solved: solved,
+		Steps:  steps,
+	}
+}
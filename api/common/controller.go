@@ -35,8 +35,14 @@ func (c *Controller) SendJSON(w http.ResponseWriter, r *http.Request, v interfac
 // MapJSON marshals v to a json struct
 // Return nil if successful, an error otherwise
 func (c *Controller) MapJSON(w http.ResponseWriter, r *http.Request, v interface{}) *errors.APIError {
-	// Maximum size of the response body is 100 bytes
-	r.Body = http.MaxBytesReader(w, r.Body, 100<<(1))
+	// Maximum size of the response body is 200 bytes
+	return c.MapJSONLimit(w, r, v, 100<<(1))
+}
+
+// MapJSONLimit marshals v to a json struct, the body being limited to maxBytes bytes
+// Return nil if successful, an error otherwise
+func (c *Controller) MapJSONLimit(w http.ResponseWriter, r *http.Request, v interface{}, maxBytes int64) *errors.APIError {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
 	bodyBuffer, err := ioutil.ReadAll(r.Body)
 
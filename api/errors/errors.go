@@ -11,3 +11,79 @@ type validationError struct {
 func BadRequest(err string) *APIError {
 	return NewAPIError(http.StatusBadRequest, "BAD_REQUEST", Params{"error": err})
 }
+
+// InvalidGridSize creates a new api error representing a sudoku of the wrong size (HTTP 400)
+func InvalidGridSize(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "INVALID_GRID_SIZE", Params{"error": err}), details)
+}
+
+// TooFewClues creates a new api error representing a sudoku without enough clues (HTTP 400)
+func TooFewClues(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "TOO_FEW_CLUES", Params{"error": err}), details)
+}
+
+// InvalidCharacter creates a new api error representing a sudoku containing an unexpected character (HTTP 400)
+func InvalidCharacter(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "INVALID_CHARACTER", Params{"error": err}), details)
+}
+
+// ConflictingClues creates a new api error representing a sudoku repeating a digit in a unit (HTTP 400)
+func ConflictingClues(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "CONFLICTING_CLUES", Params{"error": err}), details)
+}
+
+// UnknownVariant creates a new api error representing a sudoku variant which is not supported (HTTP 400)
+func UnknownVariant(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "UNKNOWN_VARIANT", Params{"error": err}), details)
+}
+
+// UnknownLayout creates a new api error representing a multi-grid layout which does not exist (HTTP 400)
+func UnknownLayout(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "UNKNOWN_LAYOUT", Params{"error": err}), details)
+}
+
+// InvalidRegions creates a new api error representing a jigsaw sudoku whose regions are not valid (HTTP 400)
+func InvalidRegions(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "INVALID_REGIONS", Params{"error": err}), details)
+}
+
+// InvalidCages creates a new api error representing a killer sudoku whose cages are not valid (HTTP 400)
+func InvalidCages(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "INVALID_CAGES", Params{"error": err}), details)
+}
+
+// InvalidEdges creates a new api error representing a sudoku whose edges between squares are not valid (HTTP 400)
+func InvalidEdges(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "INVALID_EDGES", Params{"error": err}), details)
+}
+
+// InvalidOutsideClues creates a new api error representing a sudoku whose clues outside of the grid are not valid (HTTP 400)
+func InvalidOutsideClues(err string, details interface{}) *APIError {
+	return withDetails(NewAPIError(http.StatusBadRequest, "INVALID_OUTSIDE_CLUES", Params{"error": err}), details)
+}
+
+// NoSolution creates a new api error representing a valid sudoku which has no solution (HTTP 422)
+func NoSolution(err string) *APIError {
+	return NewAPIError(http.StatusUnprocessableEntity, "NO_SOLUTION", Params{"error": err})
+}
+
+// BudgetExceeded creates a new api error representing a search stopped once its node budget was spent (HTTP 422)
+func BudgetExceeded(err string) *APIError {
+	return NewAPIError(http.StatusUnprocessableEntity, "BUDGET_EXCEEDED", Params{"error": err})
+}
+
+// Timeout creates a new api error representing a solving stopped at the deadline of its request (HTTP 504)
+func Timeout(err string) *APIError {
+	return NewAPIError(http.StatusGatewayTimeout, "TIMEOUT", Params{"error": err})
+}
+
+// Cancelled creates a new api error representing a solving stopped by the cancellation of its request (HTTP 503)
+func Cancelled(err string) *APIError {
+	return NewAPIError(http.StatusServiceUnavailable, "CANCELLED", Params{"error": err})
+}
+
+// withDetails set the additional error information of err
+func withDetails(err *APIError, details interface{}) *APIError {
+	err.Details = details
+	return err
+}
@@ -46,5 +46,47 @@ func TestErrorHandler(t *testing.T) {
 				So(err.StatusCode(), ShouldEqual, http.StatusBadRequest)
 			})
 		})
+
+		// The errors sent with a code of their own, each one with its message and details
+		noDetails := func(f func(string) *errors.APIError) func(string, interface{}) *errors.APIError {
+			return func(msg string, details interface{}) *errors.APIError { return f(msg) }
+		}
+		codes := []struct {
+			name    string
+			new     func(msg string, details interface{}) *errors.APIError
+			code    string
+			status  int
+			msg     string
+			details interface{}
+		}{
+			{"InvalidGridSize", errors.InvalidGridSize, "INVALID_GRID_SIZE", http.StatusBadRequest, "Invalid grid size", map[string]int{"expected": 81}},
+			{"TooFewClues", errors.TooFewClues, "TOO_FEW_CLUES", http.StatusBadRequest, "Too few clues", map[string]int{"clues": 12}},
+			{"InvalidCharacter", errors.InvalidCharacter, "INVALID_CHARACTER", http.StatusBadRequest, "Invalid character", map[string]string{"char": "x"}},
+			{"ConflictingClues", errors.ConflictingClues, "CONFLICTING_CLUES", http.StatusBadRequest, "A conflict occurred", map[string]string{"digit": "7"}},
+			{"UnknownVariant", errors.UnknownVariant, "UNKNOWN_VARIANT", http.StatusBadRequest, "Unknown variant", map[string]string{"variant": "killer-x"}},
+			{"UnknownLayout", errors.UnknownLayout, "UNKNOWN_LAYOUT", http.StatusBadRequest, "Unknown layout", map[string]string{"layout": "flower"}},
+			{"InvalidRegions", errors.InvalidRegions, "INVALID_REGIONS", http.StatusBadRequest, "Invalid region", map[string]string{"region": "7"}},
+			{"InvalidCages", errors.InvalidCages, "INVALID_CAGES", http.StatusBadRequest, "Invalid cage", map[string]int{"cage": 3}},
+			{"InvalidEdges", errors.InvalidEdges, "INVALID_EDGES", http.StatusBadRequest, "Invalid edge", map[string]int{"edge": 2}},
+			{"InvalidOutsideClues", errors.InvalidOutsideClues, "INVALID_OUTSIDE_CLUES", http.StatusBadRequest, "Invalid outside clue", map[string]string{"clue": "sandwich:L1=50"}},
+			{"NoSolution", noDetails(errors.NoSolution), "NO_SOLUTION", http.StatusUnprocessableEntity, "No solution", nil},
+			{"BudgetExceeded", noDetails(errors.BudgetExceeded), "BUDGET_EXCEEDED", http.StatusUnprocessableEntity, "Budget exceeded", nil},
+			{"Timeout", noDetails(errors.Timeout), "TIMEOUT", http.StatusGatewayTimeout, "Deadline exceeded", nil},
+			{"Cancelled", noDetails(errors.Cancelled), "CANCELLED", http.StatusServiceUnavailable, "Cancelled", nil},
+		}
+
+		for _, c := range codes {
+			Convey("When errors."+c.name+" is called from handler with an error message", func() {
+				err := c.new(c.msg, c.details)
+
+				Convey("Then error should have its HTTP status, its own error code, the message and the details", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, c.msg)
+					So(err.ErrorCode, ShouldEqual, c.code)
+					So(err.Details, ShouldResemble, c.details)
+					So(err.StatusCode(), ShouldEqual, c.status)
+				})
+			})
+		}
 	})
 }
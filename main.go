@@ -29,6 +29,7 @@ func main() {
 
 	// Routes handling
 	s.HandleFunc("/sudoku", sudoku.Solve).Methods("POST")
+	s.HandleFunc("/sudoku/steps", sudoku.Steps).Methods("POST")
 
 	// Create new Gracefulserver and bind listin address and handlers
 	go func(r http.Handler) {
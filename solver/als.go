@@ -0,0 +1,283 @@
+package solver
+
+import "math/bits"
+
+// maxALSSize is the number of squares of the biggest almost locked set the logical solver looks for
+const maxALSSize = 5
+
+// als is an almost locked set: n free squares of a unit holding n+1 digits together
+type als struct {
+	unit    int
+	squares []int
+	digits  uint32
+}
+
+// ALSs returns the almost locked sets of the board, each set of squares once
+func (l *logic) alss() []als {
+	var res []als
+	seen := map[string]bool{}
+	for u, unit := range l.r.unitlist {
+		if l.stopped() {
+			return nil
+		}
+		var free []int
+		for _, s := range unit {
+			if !l.placed[s] {
+				free = append(free, s)
+			}
+		}
+
+		for n := 1; n <= maxALSSize && n < len(free); n++ {
+			subsets(len(free), n, func(picked []int) bool {
+				a := als{unit: u, squares: make([]int, n)}
+				for i, p := range picked {
+					a.squares[i] = free[p]
+					a.digits |= l.values[free[p]]
+				}
+				if bits.OnesCount32(a.digits) != n+1 {
+					return false
+				}
+
+				key := ""
+				for _, s := range a.squares {
+					key += l.g.squares[s] + ","
+				}
+				if !seen[key] {
+					seen[key] = true
+					res = append(res, a)
+				}
+				return false
+			})
+		}
+	}
+	return res
+}
+
+// Holding returns the squares of the set holding digit d
+func (l *logic) holding(a als, d uint32) []int {
+	var res []int
+	for _, s := range a.squares {
+		if l.values[s]&d != 0 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Overlap report whether two sets share a square
+func overlap(a, b als) bool {
+	for _, s := range a.squares {
+		if contains(b.squares, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RestrictedCommons returns the digits of the sets a and b, not sharing any square, which can not be in both:
+// their squares holding the digit all see each other
+func (l *logic) restrictedCommons(a, b als) uint32 {
+	var res uint32
+	for x := a.digits & b.digits; x != 0; x &= x - 1 {
+		d := x & -x
+		ok := true
+		for _, s := range l.holding(a, d) {
+			ok = ok && l.seesAll(s, l.holding(b, d))
+		}
+		if ok {
+			res |= d
+		}
+	}
+	return res
+}
+
+// alsTargets returns the free squares outside of the sets holding digit d and seeing the squares of the sets
+// holding it
+func (l *logic) alsTargets(d uint32, sets ...als) []int {
+	var seen []int
+	for _, a := range sets {
+		seen = append(seen, l.holding(a, d)...)
+	}
+	var res []int
+	for _, s := range l.r.peers[seen[0]] {
+		inside := false
+		for _, a := range sets {
+			inside = inside || contains(a.squares, s)
+		}
+		if !inside && !l.placed[s] && l.values[s]&d != 0 && l.seesAll(s, seen) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// alsStep record a step based on the sets and apply the eliminations of digit z
+func (l *logic) alsStep(z uint32, targets []int, sets ...als) *Step {
+	var units, squares []int
+	var digits uint32
+	for _, a := range sets {
+		units = append(units, a.unit)
+		squares = append(squares, a.squares...)
+		digits |= a.digits
+	}
+	st := l.begin(units, squares, digits)
+	for _, s := range targets {
+		l.eliminate(s, z)
+	}
+	return st
+}
+
+// ALSXZ finds two almost locked sets sharing a restricted common digit x, which can only be in one of them:
+// the other one is then locked, so a digit z of both sets is in one of them and eliminated from the squares
+// seeing all their squares holding z
+func (l *logic) alsXZ() *Step {
+	sets := l.alss()
+	for i, a := range sets {
+		if l.stopped() {
+			return nil
+		}
+		for _, b := range sets[i+1:] {
+			if overlap(a, b) {
+				continue
+			}
+			rcc := l.restrictedCommons(a, b)
+			if rcc == 0 {
+				continue
+			}
+			for z := a.digits & b.digits &^ (rcc & -rcc); z != 0; z &= z - 1 {
+				if targets := l.alsTargets(z&-z, a, b); len(targets) > 0 {
+					return l.alsStep(z&-z, targets, a, b)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ALSXYWing finds an almost locked set c sharing a restricted common digit x with a set a and y with a set b:
+// x or y being out of c, a or b is locked, so a digit z of both a and b is eliminated from the squares seeing all
+// their squares holding z
+func (l *logic) alsXYWing() *Step {
+	sets := l.alss()
+
+	// The sets sharing a restricted common digit with each set
+	type rcc struct {
+		set    int
+		digits uint32
+	}
+	links := make([][]rcc, len(sets))
+	for i := range sets {
+		if l.stopped() {
+			return nil
+		}
+		for j := i + 1; j < len(sets); j++ {
+			if overlap(sets[i], sets[j]) {
+				continue
+			}
+			if d := l.restrictedCommons(sets[i], sets[j]); d != 0 {
+				links[i] = append(links[i], rcc{j, d})
+				links[j] = append(links[j], rcc{i, d})
+			}
+		}
+	}
+
+	for c := range sets {
+		if l.stopped() {
+			return nil
+		}
+		for i, la := range links[c] {
+			for _, lb := range links[c][i+1:] {
+				a, b := sets[la.set], sets[lb.set]
+				if overlap(a, b) {
+					continue
+				}
+				// Two different digits x and y
+				for xs := la.digits; xs != 0; xs &= xs - 1 {
+					for ys := lb.digits; ys != 0; ys &= ys - 1 {
+						x, y := xs&-xs, ys&-ys
+						if x == y {
+							continue
+						}
+						for z := a.digits & b.digits &^ (x | y); z != 0; z &= z - 1 {
+							if targets := l.alsTargets(z&-z, a, b); len(targets) > 0 {
+								return l.alsStep(z&-z, targets, a, b, sets[c])
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SueDeCoq finds n free squares shared by a box and a line holding n+2 digits, a square of the rest of the line
+// holding two of them and a square of the rest of the box holding two others: the shared squares hold one digit
+// of each of these squares and the other digits, which are eliminated from the rest of the line but the digits of
+// the box square, and from the rest of the box but the digits of the line square
+func (l *logic) sueDeCoq() *Step {
+	for b, box := range l.r.unitlist {
+		if l.kinds[b] != unitBox {
+			continue
+		}
+		for _, v := range l.lines(box) {
+			var shared, lineRest, boxRest []int
+			var digits uint32
+			for _, s := range box {
+				if !l.placed[s] && contains(l.r.unitlist[v], s) {
+					shared = append(shared, s)
+					digits |= l.values[s]
+				} else if !l.placed[s] {
+					boxRest = append(boxRest, s)
+				}
+			}
+			for _, s := range l.r.unitlist[v] {
+				if !l.placed[s] && !contains(box, s) {
+					lineRest = append(lineRest, s)
+				}
+			}
+			if len(shared) < 2 || bits.OnesCount32(digits) != len(shared)+2 {
+				continue
+			}
+
+			for _, ls := range lineRest {
+				dl := l.values[ls]
+				if bits.OnesCount32(dl) != 2 || dl&^digits != 0 {
+					continue
+				}
+				for _, bs := range boxRest {
+					db := l.values[bs]
+					if bits.OnesCount32(db) != 2 || db&^digits != 0 || db&dl != 0 {
+						continue
+					}
+
+					lineOut, boxOut := digits&^db, digits&^dl
+					removable := false
+					for _, s := range lineRest {
+						removable = removable || s != ls && l.values[s]&lineOut != 0
+					}
+					for _, s := range boxRest {
+						removable = removable || s != bs && l.values[s]&boxOut != 0
+					}
+					if !removable {
+						continue
+					}
+
+					st := l.begin([]int{b, v}, append(shared, ls, bs), digits)
+					for _, s := range lineRest {
+						if s != ls {
+							l.eliminate(s, lineOut)
+						}
+					}
+					for _, s := range boxRest {
+						if s != bs {
+							l.eliminate(s, boxOut)
+						}
+					}
+					return st
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,617 @@
+package solver
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// maxChainLinks is the number of links of the longest chain the logical solver looks for
+const maxChainLinks = 20
+
+// Node is a digit held by a square of a chain, or by one of a group of squares shared by a box and a line
+type Node struct {
+	Digit   string   `json:"digit"`
+	Squares []string `json:"squares"`
+}
+
+// String returns the node in the Eureka notation, like (4)A1, or (4)A1A2 for a group
+func (n Node) String() string {
+	return "(" + n.Digit + ")" + strings.Join(n.Squares, "")
+}
+
+// Link joins two nodes of a chain. A strong link tells that one of the nodes at least holds its digit,
+// a weak link that they do not both hold it.
+type Link struct {
+	From   Node `json:"from"`
+	To     Node `json:"to"`
+	Strong bool `json:"strong"`
+}
+
+// ChainString writes the links in the Eureka notation, = for the strong links and - for the weak ones,
+// like (4)A1=(4)A5-(4)C5=(4)C7
+func chainString(chain []Link) string {
+	var sb strings.Builder
+	for i, lk := range chain {
+		if i == 0 {
+			sb.WriteString(lk.From.String())
+		}
+		if lk.Strong {
+			sb.WriteString("=")
+		} else {
+			sb.WriteString("-")
+		}
+		sb.WriteString(lk.To.String())
+	}
+	return sb.String()
+}
+
+// chainNode is a node of the chains, the digit d held by one of the squares
+type chainNode struct {
+	d       uint32
+	squares []int
+}
+
+// chainRules tells which nodes and links the chains of a technique go through
+type chainRules struct {
+	// digits are the digits of the nodes
+	digits uint32
+	// groups allows the nodes made of the squares shared by a box and a line
+	groups bool
+	// bivalue keeps the nodes of the squares with two candidates only
+	bivalue bool
+	// units makes strong links between the only two places of a digit in a unit
+	units bool
+	// cells links the two digits of a square with two candidates, and weakly every digits of a square
+	cells bool
+}
+
+// chainGraph holds the nodes of the chains and their links. The state of a node in a chain, on when it holds its
+// digit and off otherwise, is numbered 2*node+1 when on: a strong link leads from an off state to an on state,
+// a weak link from an on state to an off state.
+type chainGraph struct {
+	l      *logic
+	nodes  []chainNode
+	strong [][]int
+	weak   [][]int
+	// at holds the nodes of each square
+	at [][]int
+}
+
+// ChainGraph build the nodes and the links of the chains following the rules
+func (l *logic) chainGraph(cr chainRules) *chainGraph {
+	g := &chainGraph{l: l, at: make([][]int, len(l.values))}
+	keys := map[string]int{}
+	key := func(d uint32, squares []int) string {
+		var sb strings.Builder
+		sb.WriteString(digitString(d))
+		for _, s := range squares {
+			sb.WriteString("," + l.g.squares[s])
+		}
+		return sb.String()
+	}
+	add := func(d uint32, squares []int) {
+		k := key(d, squares)
+		if _, ok := keys[k]; ok {
+			return
+		}
+		keys[k] = len(g.nodes)
+		for _, s := range squares {
+			g.at[s] = append(g.at[s], len(g.nodes))
+		}
+		g.nodes = append(g.nodes, chainNode{d: d, squares: squares})
+	}
+
+	for s, v := range l.values {
+		if l.placed[s] || cr.bivalue && bits.OnesCount32(v) != 2 {
+			continue
+		}
+		for x := v & cr.digits; x != 0; x &= x - 1 {
+			add(x&-x, []int{s})
+		}
+	}
+	singles := len(g.nodes)
+
+	if cr.groups {
+		for b, box := range l.r.unitlist {
+			if l.kinds[b] != unitBox {
+				continue
+			}
+			for _, v := range l.lines(box) {
+				for x := cr.digits; x != 0; x &= x - 1 {
+					var squares []int
+					for _, s := range box {
+						if !l.placed[s] && l.values[s]&x&-x != 0 && contains(l.r.unitlist[v], s) {
+							squares = append(squares, s)
+						}
+					}
+					if len(squares) >= 2 {
+						add(x&-x, squares)
+					}
+				}
+			}
+		}
+	}
+
+	g.strong = make([][]int, len(g.nodes))
+	g.weak = make([][]int, len(g.nodes))
+	link := func(links [][]int, a, b int) {
+		if !contains(links[a], b) {
+			links[a] = append(links[a], b)
+			links[b] = append(links[b], a)
+		}
+	}
+
+	if cr.units {
+		for u, unit := range l.r.unitlist {
+			for x := cr.digits; x != 0; x &= x - 1 {
+				d := x & -x
+				var places []int
+				placed := false
+				for _, s := range unit {
+					if l.values[s]&d != 0 {
+						places = append(places, s)
+						placed = placed || l.placed[s]
+					}
+				}
+				if placed || len(places) < 2 {
+					continue
+				}
+
+				a, okA := keys[key(d, places[:1])]
+				b, okB := keys[key(d, places[1:])]
+				if len(places) == 2 && okA && okB {
+					link(g.strong, a, b)
+				}
+				// A group of the unit and the rest of the places, a square or another group
+				for n := singles; n < len(g.nodes); n++ {
+					if g.nodes[n].d != d || !l.within(g.nodes[n].squares, u) {
+						continue
+					}
+					var rest []int
+					for _, s := range places {
+						if !contains(g.nodes[n].squares, s) {
+							rest = append(rest, s)
+						}
+					}
+					if m, ok := keys[key(d, rest)]; ok && len(rest) > 0 {
+						link(g.strong, n, m)
+					}
+				}
+			}
+		}
+	}
+
+	for n, node := range g.nodes {
+		if cr.cells && len(node.squares) == 1 {
+			s := node.squares[0]
+			for _, m := range g.at[s] {
+				if m == n || len(g.nodes[m].squares) != 1 {
+					continue
+				}
+				link(g.weak, n, m)
+				if bits.OnesCount32(l.values[s]) == 2 {
+					link(g.strong, n, m)
+				}
+			}
+		}
+
+		// The nodes of the same digit whose squares all see the squares of the node
+		for _, p := range l.r.peers[node.squares[0]] {
+			for _, m := range g.at[p] {
+				other := g.nodes[m]
+				if other.d != node.d || contains(g.weak[n], m) {
+					continue
+				}
+				sees := true
+				for _, s := range other.squares {
+					sees = sees && l.seesAll(s, node.squares)
+				}
+				if sees {
+					link(g.weak, n, m)
+				}
+			}
+		}
+	}
+	return g
+}
+
+// Lines returns the rows and the columns going through the squares
+func (l *logic) lines(squares []int) []int {
+	var res []int
+	for _, s := range squares {
+		for _, v := range l.r.units[s] {
+			if (l.kinds[v] == unitRow || l.kinds[v] == unitColumn) && !contains(res, v) {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// chainDeduction holds the digits a chain eliminates from squares, or the digit it places in a square
+type chainDeduction struct {
+	squares []int
+	digits  []uint32
+	place   bool
+}
+
+// Shortest finds the shortest chain from one of the start states to a state concluding a deduction,
+// looking at the chains of at most maxChainLinks links. Conclude is given the states the chain begins and ends
+// with. The chain is returned as the states it goes through.
+func (g *chainGraph) shortest(starts []int, conclude func(first, last int) *chainDeduction) ([]int, *chainDeduction) {
+	var best []int
+	var deduction *chainDeduction
+
+	parent := make([]int, 2*len(g.nodes))
+	dist := make([]int, 2*len(g.nodes))
+	for _, start := range starts {
+		if g.l.stopped() {
+			return nil, nil
+		}
+		for i := range dist {
+			dist[i] = -1
+		}
+		dist[start] = 0
+		queue := []int{start}
+
+	search:
+		for len(queue) > 0 {
+			st := queue[0]
+			queue = queue[1:]
+			if dist[st] >= maxChainLinks || best != nil && dist[st]+1 >= len(best)-1 {
+				break
+			}
+
+			links := g.strong[st/2]
+			if st%2 == 1 {
+				links = g.weak[st/2]
+			}
+			for _, n := range links {
+				next := 2*n + 1 - st%2
+				if dist[next] >= 0 {
+					continue
+				}
+				dist[next], parent[next] = dist[st]+1, st
+				if d := conclude(start, next); d != nil {
+					best, deduction = make([]int, dist[next]+1), d
+					for i, at := len(best)-1, next; i >= 0; i, at = i-1, parent[at] {
+						best[i] = at
+					}
+					break search
+				}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return best, deduction
+}
+
+// Apply record a step for the chain going through the states, and apply its deduction
+func (g *chainGraph) apply(path []int, d *chainDeduction) *Step {
+	nodes := make([]int, len(path))
+	strong := make([]bool, len(path))
+	for i, st := range path {
+		nodes[i], strong[i] = st/2, st%2 == 1
+	}
+	return g.record(nodes, strong, d)
+}
+
+// Record a step for the chain going through the nodes, strong[i] telling whether the link to node i is strong,
+// and apply its deduction
+func (g *chainGraph) record(nodes []int, strong []bool, d *chainDeduction) *Step {
+	l := g.l
+	var squares []int
+	var digits uint32
+	for _, n := range nodes {
+		digits |= g.nodes[n].d
+		for _, s := range g.nodes[n].squares {
+			if !contains(squares, s) {
+				squares = append(squares, s)
+			}
+		}
+	}
+
+	st := l.begin(nil, squares, digits)
+	for i := 1; i < len(nodes); i++ {
+		st.Chain = append(st.Chain, Link{From: g.node(nodes[i-1]), To: g.node(nodes[i]), Strong: strong[i]})
+	}
+
+	for i, s := range d.squares {
+		if d.place {
+			l.place(s, d.digits[i])
+		} else {
+			l.eliminate(s, d.digits[i])
+		}
+	}
+	return st
+}
+
+// Node convert a node of the graph
+func (g *chainGraph) node(n int) Node {
+	res := Node{Digit: digitString(g.nodes[n].d)}
+	for _, s := range g.nodes[n].squares {
+		res.Squares = append(res.Squares, g.l.g.squares[s])
+	}
+	return res
+}
+
+// Seers returns the squares holding digit d as a candidate which see all the squares of the nodes, and are not
+// part of them
+func (g *chainGraph) seers(d uint32, nodes ...int) []int {
+	var all []int
+	for _, n := range nodes {
+		all = append(all, g.nodes[n].squares...)
+	}
+	var res []int
+	for _, s := range g.l.r.peers[all[0]] {
+		if !g.l.placed[s] && g.l.values[s]&d != 0 && !contains(all, s) && g.l.seesAll(s, all) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// eliminations returns the deduction eliminating digit d from the squares, nil if there are none
+func eliminations(squares []int, d uint32) *chainDeduction {
+	if len(squares) == 0 {
+		return nil
+	}
+	res := &chainDeduction{squares: squares}
+	for range squares {
+		res.digits = append(res.digits, d)
+	}
+	return res
+}
+
+// offStarts returns the off states of every node, the states a chain starting with a strong link begins with
+func (g *chainGraph) offStarts() []int {
+	res := make([]int, len(g.nodes))
+	for n := range res {
+		res[n] = 2 * n
+	}
+	return res
+}
+
+// sameDigitEnds conclude the chains starting off and ending on two nodes of the same digit: one of them holds it,
+// so it is eliminated from the squares seeing both
+func (g *chainGraph) sameDigitEnds(start, end int) *chainDeduction {
+	first, last := start/2, end/2
+	if start%2 == 1 || end%2 == 0 || first == last || g.nodes[first].d != g.nodes[last].d {
+		return nil
+	}
+	return eliminations(g.seers(g.nodes[first].d, first, last), g.nodes[first].d)
+}
+
+// loopEnds conclude the chains starting off and going back to the same node on: it holds its digit. A node off
+// whenever it is on is left to the chains ending on two squares seeing it.
+func (g *chainGraph) loopEnds(start, end int) *chainDeduction {
+	n := g.nodes[start/2]
+	if start/2 != end/2 || start%2 == 1 || end%2 == 0 || len(n.squares) != 1 {
+		return nil
+	}
+	return &chainDeduction{squares: n.squares, digits: []uint32{n.d}, place: true}
+}
+
+// otherDigitEnds conclude the chains starting off and ending on two single squares with different digits x and y:
+// the square of x can not hold y if it sees the other square, and the reverse, and a square holding both ends
+// holds one of them
+func (g *chainGraph) otherDigitEnds(start, end int) *chainDeduction {
+	first, last := g.nodes[start/2], g.nodes[end/2]
+	if start%2 == 1 || end%2 == 0 || first.d == last.d || len(first.squares) != 1 || len(last.squares) != 1 {
+		return nil
+	}
+	a, b := first.squares[0], last.squares[0]
+	l := g.l
+
+	res := &chainDeduction{}
+	switch {
+	case a == b:
+		if others := l.values[a] &^ (first.d | last.d); others != 0 {
+			res.squares, res.digits = []int{a}, []uint32{others}
+		}
+	case contains(l.r.peers[a], b):
+		if l.values[a]&last.d != 0 {
+			res.squares, res.digits = append(res.squares, a), append(res.digits, last.d)
+		}
+		if l.values[b]&first.d != 0 {
+			res.squares, res.digits = append(res.squares, b), append(res.digits, first.d)
+		}
+	}
+	if len(res.squares) == 0 {
+		return nil
+	}
+	return res
+}
+
+// XChain finds a chain of a single digit starting and ending with strong links, and eliminates the digit from the
+// squares seeing both ends
+func (l *logic) xChain() *Step {
+	for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+		g := l.chainGraph(chainRules{digits: d, units: true})
+		if path, dd := g.shortest(g.offStarts(), g.sameDigitEnds); path != nil {
+			return g.apply(path, dd)
+		}
+	}
+	return nil
+}
+
+// XCycle finds a loop of a single digit through a square, on whenever it is off: the digit is placed in it.
+// It comes before the x-chains, which would eliminate the digit from the square ending the loop. A loop
+// eliminating the digit from a square, off whenever on, is the x-chain of the two squares the loop goes through
+// next to it.
+func (l *logic) xCycle() *Step {
+	for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+		g := l.chainGraph(chainRules{digits: d, units: true})
+		if path, dd := g.shortest(g.offStarts(), g.loopEnds); path != nil {
+			return g.apply(path, dd)
+		}
+	}
+	return nil
+}
+
+// XYChain finds a chain of squares with two candidates starting and ending with the same digit, and eliminates
+// it from the squares seeing both ends
+func (l *logic) xyChain() *Step {
+	g := l.chainGraph(chainRules{digits: l.g.allDigits, bivalue: true, cells: true})
+	if path, dd := g.shortest(g.offStarts(), g.sameDigitEnds); path != nil {
+		return g.apply(path, dd)
+	}
+	return nil
+}
+
+// AIC finds an alternating inference chain of any digits, squares and groups of squares, and concludes from its
+// ends or from a loop
+func (l *logic) aic() *Step {
+	g := l.chainGraph(chainRules{digits: l.g.allDigits, groups: true, units: true, cells: true})
+	conclude := func(start, end int) *chainDeduction {
+		if d := g.sameDigitEnds(start, end); d != nil {
+			return d
+		}
+		if d := g.otherDigitEnds(start, end); d != nil {
+			return d
+		}
+		return g.loopEnds(start, end)
+	}
+	if path, dd := g.shortest(g.offStarts(), conclude); path != nil {
+		return g.apply(path, dd)
+	}
+	return nil
+}
+
+// colors splits the nodes linked by strong links into clusters, two nodes strongly linked having opposite colors.
+// It returns the cluster of each node, -1 for the nodes without strong links, and its color.
+func (g *chainGraph) colors() ([]int, []int) {
+	cluster := make([]int, len(g.nodes))
+	color := make([]int, len(g.nodes))
+	for n := range cluster {
+		cluster[n] = -1
+	}
+	count := 0
+	for n := range g.nodes {
+		if cluster[n] >= 0 || len(g.strong[n]) == 0 {
+			continue
+		}
+		cluster[n] = count
+		queue := []int{n}
+		for len(queue) > 0 {
+			m := queue[0]
+			queue = queue[1:]
+			for _, o := range g.strong[m] {
+				if cluster[o] < 0 {
+					cluster[o], color[o] = count, 1-color[m]
+					queue = append(queue, o)
+				}
+			}
+		}
+		count++
+	}
+	return cluster, color
+}
+
+// strongPath returns the nodes going from node a to node b of the same cluster through strong links
+func (g *chainGraph) strongPath(a, b int) []int {
+	parent := map[int]int{a: a}
+	queue := []int{a}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		for _, o := range g.strong[m] {
+			if _, ok := parent[o]; !ok {
+				parent[o] = m
+				queue = append(queue, o)
+			}
+		}
+	}
+
+	res := []int{b}
+	for n := b; n != a; n = parent[n] {
+		res = append([]int{parent[n]}, res...)
+	}
+	return res
+}
+
+// ColoredChain record the step of a chain going through the nodes, linked by the strong links of coloring
+// unless they only see each other
+func (g *chainGraph) coloredChain(nodes []int, d *chainDeduction) *Step {
+	strong := make([]bool, len(nodes))
+	for i := 1; i < len(nodes); i++ {
+		strong[i] = contains(g.strong[nodes[i-1]], nodes[i])
+	}
+	return g.record(nodes, strong, d)
+}
+
+// SimpleColoring colors the squares linked by strong links of a digit with two colors, one of them holding the
+// digit. Two squares of the same color seeing each other rule that color out, and the squares seeing both colors
+// can not hold the digit.
+func (l *logic) simpleColoring() *Step {
+	for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+		g := l.chainGraph(chainRules{digits: d, units: true})
+		cluster, color := g.colors()
+
+		for a := range g.nodes {
+			for _, b := range g.weak[a] {
+				if cluster[a] < 0 || cluster[a] != cluster[b] || color[a] != color[b] {
+					continue
+				}
+
+				// The color of a and b is wrong: eliminate the digit from its squares
+				var squares []int
+				for n := range g.nodes {
+					if cluster[n] == cluster[a] && color[n] == color[a] {
+						squares = append(squares, g.nodes[n].squares[0])
+					}
+				}
+				return g.coloredChain(append(g.strongPath(a, b), a), eliminations(squares, d))
+			}
+		}
+
+		for a := range g.nodes {
+			for b := range g.nodes {
+				if cluster[a] < 0 || cluster[a] != cluster[b] || color[a] != 0 || color[b] != 1 {
+					continue
+				}
+				if squares := g.seers(d, a, b); len(squares) > 0 {
+					return g.coloredChain(g.strongPath(a, b), eliminations(squares, d))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MultiColoring looks at two clusters of squares colored by simple coloring: when a color of each cluster see each
+// other, one of the other colors holds the digit, and the squares seeing both of them can not hold it
+func (l *logic) multiColoring() *Step {
+	for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+		g := l.chainGraph(chainRules{digits: d, units: true})
+		cluster, color := g.colors()
+
+		done := map[[4]int]bool{}
+		for a1 := range g.nodes {
+			for _, a2 := range g.weak[a1] {
+				k := [4]int{cluster[a1], color[a1], cluster[a2], color[a2]}
+				if cluster[a1] < 0 || cluster[a2] < 0 || cluster[a1] == cluster[a2] || done[k] {
+					continue
+				}
+				done[k] = true
+
+				for b1 := range g.nodes {
+					if cluster[b1] != cluster[a1] || color[b1] == color[a1] {
+						continue
+					}
+					for b2 := range g.nodes {
+						if cluster[b2] != cluster[a2] || color[b2] == color[a2] {
+							continue
+						}
+						if squares := g.seers(d, b1, b2); len(squares) > 0 {
+							// b1 = ... = a1 - a2 = ... = b2
+							nodes := append(g.strongPath(b1, a1), g.strongPath(a2, b2)...)
+							return g.coloredChain(nodes, eliminations(squares, d))
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
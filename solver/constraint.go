@@ -0,0 +1,174 @@
+package solver
+
+import "fmt"
+
+// Constraint is a rule the solution of a sudoku must follow, on top of the rows, columns and boxes.
+// Squares are identified by their index, row by row (0 is A1, 1 is A2,...), and the candidates of a square
+// are a bitmask where bit d-1 is set when the d-th symbol of the geometry is possible.
+type Constraint interface {
+	// Squares returns the squares watched by the constraint
+	Squares(g *Geometry) []int
+	// Propagate is called with s = -1 before the clues are placed, then each time a candidate is eliminated
+	// from s, one of the squares watched. It prunes the candidates of the board which break the rule and
+	// returns false if a contradiction is detected, which it must do at the latest once all its squares are filled.
+	Propagate(b *Board, s int) bool
+}
+
+// UnitProvider is implemented by the constraints made of units: groups of Size squares holding each symbol once.
+// The units are added to the rows, columns and boxes, so their squares become peers and the hidden singles
+// they contain are found by the propagation.
+type UnitProvider interface {
+	Units(g *Geometry) [][]int
+}
+
+// PeerProvider is implemented by the constraints forbidding pairs of squares to hold the same value,
+// like the squares a knight's move away of the anti-knight sudoku. The squares of each pair become peers.
+type PeerProvider interface {
+	Peers(g *Geometry) [][2]int
+}
+
+// Compiler is implemented by the constraints described independently of the geometry, with the names of
+// their squares for instance. They are replaced by the constraint Compile returns for the geometry of the
+// sudoku, which can precompute what its propagation needs. Compile returns an error if the description
+// is not valid for the geometry.
+type Compiler interface {
+	Compile(g *Geometry) (Constraint, error)
+}
+
+// NopConstraint watches no square and prunes nothing, embed it in the constraints only made of units
+type NopConstraint struct{}
+
+// Squares returns no square
+func (NopConstraint) Squares(g *Geometry) []int {
+	return nil
+}
+
+// Propagate has nothing to prune
+func (NopConstraint) Propagate(b *Board, s int) bool {
+	return true
+}
+
+// ClassicUnits is the constraint of every sudoku: each row, column and box holds each symbol once.
+// It is always applied, the constraints set with WithConstraints come in addition to it.
+type ClassicUnits struct {
+	NopConstraint
+}
+
+// Units returns the columns, rows and boxes of the geometry
+func (ClassicUnits) Units(g *Geometry) [][]int {
+	return g.unitlist
+}
+
+// Board gives the constraints access to the candidates of the squares while the propagation runs
+type Board struct {
+	p      *propagator
+	values board
+}
+
+// Geometry returns the shape of the sudoku
+func (b *Board) Geometry() *Geometry {
+	return b.p.g
+}
+
+// Candidates returns the possible values of square s as a bitmask
+func (b *Board) Candidates(s int) uint32 {
+	return b.values[s]
+}
+
+// Eliminate removes the candidates set in digits from square s and propagates.
+// Return false if a contradiction is detected.
+func (b *Board) Eliminate(s int, digits uint32) bool {
+	for digits &= b.values[s]; digits != 0; digits &= digits - 1 {
+		if !b.p.eliminate(b.values, s, digits&-digits, ByConstraint) {
+			return false
+		}
+	}
+	return true
+}
+
+// Keep removes every candidate of square s not set in digits and propagates.
+// Return false if a contradiction is detected.
+func (b *Board) Keep(s int, digits uint32) bool {
+	return b.Eliminate(s, ^digits)
+}
+
+// Rules holds the tables the propagation runs on, derived from the geometry and the constraints of a sudoku
+type rules struct {
+	g           *Geometry
+	constraints []Constraint
+
+	// unitlist holds the units of every constraint
+	unitlist [][]int
+	// units holds the indexes in unitlist of the units of each square
+	units [][]int
+	// peers holds the squares sharing a unit with each square
+	peers [][]int
+	// watchers holds the indexes of the constraints watching each square
+	watchers [][]int
+}
+
+// newRules derive the propagation tables of a sudoku from its geometry, the classic units and the constraints
+func newRules(g *Geometry, cs []Constraint) (*rules, error) {
+	if len(cs) == 0 && g.rules != nil {
+		return g.rules, nil
+	}
+
+	nbSquares := len(g.squares)
+	r := &rules{
+		g:           g,
+		constraints: append([]Constraint{ClassicUnits{}}, cs...),
+		watchers:    make([][]int, nbSquares),
+	}
+
+	// pairs holds the squares made peers by the constraints
+	var pairs [][2]int
+
+	inGrid := func(s int) bool {
+		return s >= 0 && s < nbSquares
+	}
+
+	for i, c := range r.constraints {
+		if cp, ok := c.(Compiler); ok {
+			compiled, err := cp.Compile(g)
+			if err != nil {
+				return nil, err
+			}
+			r.constraints[i], c = compiled, compiled
+		}
+
+		if up, ok := c.(UnitProvider); ok {
+			for _, unit := range up.Units(g) {
+				if len(unit) != g.size {
+					return nil, fmt.Errorf("Invalid constraint %T: expected units of %d squares found %d", c, g.size, len(unit))
+				}
+				for _, s := range unit {
+					if !inGrid(s) {
+						return nil, fmt.Errorf("Invalid constraint %T: square %d is out of the grid", c, s)
+					}
+				}
+				r.unitlist = append(r.unitlist, unit)
+			}
+		}
+
+		if pp, ok := c.(PeerProvider); ok {
+			for _, p := range pp.Peers(g) {
+				if !inGrid(p[0]) || !inGrid(p[1]) || p[0] == p[1] {
+					return nil, fmt.Errorf("Invalid constraint %T: pair %v is not made of two squares of the grid", c, p)
+				}
+				pairs = append(pairs, p)
+			}
+		}
+
+		for _, s := range c.Squares(g) {
+			if !inGrid(s) {
+				return nil, fmt.Errorf("Invalid constraint %T: square %d is out of the grid", c, s)
+			}
+			r.watchers[s] = append(r.watchers[s], i)
+		}
+	}
+
+	r.units = createUnits(nbSquares, r.unitlist)
+	r.peers = createPeers(r.unitlist, r.units, pairs)
+
+	return r, nil
+}
@@ -0,0 +1,109 @@
+package solver_test
+
+import (
+	"context"
+	"math/bits"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// centreDots is the unit made of the centres of the boxes
+type centreDots struct{ solver.NopConstraint }
+
+func (centreDots) Units(g *solver.Geometry) [][]int {
+	return [][]int{{10, 13, 16, 37, 40, 43, 64, 67, 70}}
+}
+
+// increasing requires the values of its squares to be increasing
+type increasing []int
+
+func (c increasing) Squares(g *solver.Geometry) []int { return c }
+
+func (c increasing) Propagate(b *solver.Board, s int) bool {
+	for i := 1; i < len(c); i++ {
+		// The square can not hold a value lower than or equal to the lowest value of the previous one
+		lowest := b.Candidates(c[i-1]) & -b.Candidates(c[i-1])
+		if !b.Keep(c[i], ^(lowest<<1 - 1)) {
+			return false
+		}
+	}
+	for i := len(c) - 2; i >= 0; i-- {
+		// The square can not hold a value greater than or equal to the highest value of the next one
+		highest := uint32(1) << uint(31-bits.LeadingZeros32(b.Candidates(c[i+1])))
+		if !b.Keep(c[i], highest-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// impossible rejects every board
+type impossible struct{ solver.NopConstraint }
+
+func (impossible) Propagate(b *solver.Board, s int) bool { return false }
+
+// shortUnit has a unit missing squares
+type shortUnit struct{ centreDots }
+
+func (shortUnit) Units(g *solver.Geometry) [][]int { return [][]int{{0, 1, 2}} }
+
+func TestSudokuConstraints(t *testing.T) {
+	Convey("Given an empty grid and a solver", t, func() {
+		blankGrid := strings.Repeat(".", 81)
+		lenient := solver.WithValidation(solver.LenientValidation)
+
+		Convey("When SolveContext is called with an extra unit", func() {
+			values, err := solver.SolveContext(context.Background(), blankGrid, lenient, solver.WithConstraints(centreDots{}))
+
+			Convey("Then the unit holds every digit once", func() {
+				So(err, ShouldBeNil)
+				seen := map[string]bool{}
+				for _, s := range []string{"B2", "B5", "B8", "E2", "E5", "E8", "H2", "H5", "H8"} {
+					seen[values[s]] = true
+				}
+				So(seen, ShouldHaveLength, 9)
+			})
+		})
+
+		Convey("When SolveContext is called with a constraint pruning the candidates", func() {
+			values, err := solver.SolveContext(context.Background(), blankGrid, lenient,
+				solver.WithConstraints(increasing{0, 1, 2, 3, 4, 5, 6, 7, 8}))
+
+			Convey("Then the solution follows the constraint", func() {
+				So(err, ShouldBeNil)
+				for c := 1; c <= 9; c++ {
+					So(values["A"+strconv.Itoa(c)], ShouldEqual, strconv.Itoa(c))
+				}
+			})
+		})
+
+		Convey("When CountSolutions is called with a constraint contradicting the clues", func() {
+			n, err := solver.CountSolutions(grid, 0, solver.WithConstraints(increasing{0, 1}))
+
+			Convey("Then no solution is found", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When Solve is called with a constraint which can never be satisfied", func() {
+			_, err := solver.SolveContext(context.Background(), grid, solver.WithConstraints(impossible{}))
+
+			Convey("Then return ErrNoSolution", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
+			})
+		})
+
+		Convey("When Solve is called with a unit missing squares", func() {
+			_, err := solver.SolveContext(context.Background(), grid, solver.WithConstraints(shortUnit{}))
+
+			Convey("Then return an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
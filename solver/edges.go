@@ -0,0 +1,120 @@
+package solver
+
+// EdgeKind names the relation marked on the border between two adjacent squares
+type EdgeKind string
+
+const (
+	// WhiteDot is the white dot of the Kropki sudoku: the values are consecutive
+	WhiteDot EdgeKind = "white"
+	// BlackDot is the black dot of the Kropki sudoku: one value is the double of the other
+	BlackDot EdgeKind = "black"
+	// EdgeX is the X of the XV sudoku: the values add up to 10
+	EdgeX EdgeKind = "x"
+	// EdgeV is the V of the XV sudoku: the values add up to 5
+	EdgeV EdgeKind = "v"
+	// GreaterThan is the sign of the greater-than sudoku: the value of the first square is the biggest
+	GreaterThan EdgeKind = ">"
+)
+
+// edgeRelations holds the relation of each kind of edge
+var edgeRelations = map[EdgeKind]Relation{
+	WhiteDot:    func(a, b int) bool { return a-b == 1 || b-a == 1 },
+	BlackDot:    func(a, b int) bool { return a == 2*b || b == 2*a },
+	EdgeX:       func(a, b int) bool { return a+b == 10 },
+	EdgeV:       func(a, b int) bool { return a+b == 5 },
+	GreaterThan: func(a, b int) bool { return a > b },
+}
+
+// Edge marks a relation between two adjacent squares, named like A1 or B5
+type Edge struct {
+	Cells [2]string `json:"cells"`
+	Kind  EdgeKind  `json:"kind"`
+}
+
+// Edges is the constraint of the dot and border variants: Kropki, XV and greater-than sudokus.
+// Negative lists the kinds of edges which are all given: the adjacent squares without an edge of one of
+// these kinds do not hold its relation, e.g. with the white and black dots of a Kropki sudoku the values
+// of the squares without a dot are neither consecutive nor one the double of the other.
+type Edges struct {
+	NopConstraint
+	Edges    []Edge     `json:"edges"`
+	Negative []EdgeKind `json:"negative"`
+}
+
+// Compile the edges into pairs of squares, with the negative relation on the pairs of adjacent squares
+// without an edge of one of the negative kinds. Return an *EdgeError if an edge or a negative kind is not valid.
+func (e Edges) Compile(g *Geometry) (Constraint, error) {
+	pc := &pairwise{pairsOf: make([][]int, len(g.squares))}
+
+	tables := map[EdgeKind]int{}
+	// marked holds the kinds of the edges between each pair of squares, both ways
+	marked := map[[2]int]map[EdgeKind]bool{}
+	for i, edge := range e.Edges {
+		rel, ok := edgeRelations[edge.Kind]
+		if !ok {
+			return nil, &EdgeError{Edge: i, Kind: string(edge.Kind)}
+		}
+
+		var pr [2]int
+		for j, name := range edge.Cells {
+			if pr[j] = g.SquareIndex(name); pr[j] < 0 {
+				return nil, &EdgeError{Edge: i, Cell: name}
+			}
+		}
+		if !adjacent(g, pr[0], pr[1]) {
+			return nil, &EdgeError{Edge: i, Cells: edge.Cells[:]}
+		}
+
+		if _, ok := tables[edge.Kind]; !ok {
+			tables[edge.Kind] = len(pc.tables)
+			pc.tables = append(pc.tables, newRelationTable(g, rel))
+		}
+		pc.add(g, pr, tables[edge.Kind])
+		for _, p := range [][2]int{pr, {pr[1], pr[0]}} {
+			if marked[p] == nil {
+				marked[p] = map[EdgeKind]bool{}
+			}
+			marked[p][edge.Kind] = true
+		}
+	}
+
+	if len(e.Negative) == 0 {
+		return pc, nil
+	}
+
+	for _, kind := range e.Negative {
+		if _, ok := edgeRelations[kind]; !ok || kind == GreaterThan {
+			return nil, &EdgeError{Edge: -1, Kind: string(kind)}
+		}
+	}
+
+	pc.tables = append(pc.tables, newRelationTable(g, func(a, b int) bool {
+		for _, kind := range e.Negative {
+			if edgeRelations[kind](a, b) {
+				return false
+			}
+		}
+		return true
+	}))
+
+	// The pairs having an edge of one of the negative kinds are left out, a dot between 1 and 2 being white or
+	// black. The pairs only having other edges, like greater-than signs, still hold none of the relations.
+	for _, pr := range moves(g, [][2]int{{0, 1}, {1, 0}}) {
+		exempt := false
+		for _, kind := range e.Negative {
+			exempt = exempt || marked[pr][kind]
+		}
+		if !exempt {
+			pc.add(g, pr, len(pc.tables)-1)
+		}
+	}
+
+	return pc, nil
+}
+
+// Adjacent report whether the squares are next to each other on a row or a column
+func adjacent(g *Geometry, s1, s2 int) bool {
+	r1, c1 := g.position(s1)
+	r2, c2 := g.position(s2)
+	return (r1 == r2 && (c1-c2 == 1 || c2-c1 == 1)) || (c1 == c2 && (r1-r2 == 1 || r2-r1 == 1))
+}
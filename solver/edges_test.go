@@ -0,0 +1,130 @@
+package solver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// kropkiEdges are the dots of a Kropki sudoku without clues, whose solution is the one of the killer sudoku
+const kropkiEdges = `[{"cells":["A4","A5"],"kind":"black"},{"cells":["A5","B5"],"kind":"white"},{"cells":["A6","A7"],"kind":"white"},{"cells":["A6","B6"],"kind":"white"},{"cells":["A7","B7"],"kind":"white"},{"cells":["A8","B8"],"kind":"black"},{"cells":["B1","B2"],"kind":"black"},{"cells":["B2","B3"],"kind":"white"},{"cells":["B3","B4"],"kind":"white"},{"cells":["B6","B7"],"kind":"white"},{"cells":["B6","C6"],"kind":"black"},{"cells":["B9","C9"],"kind":"white"},{"cells":["C1","D1"],"kind":"white"},{"cells":["C3","C4"],"kind":"white"},{"cells":["C5","C6"],"kind":"black"},{"cells":["C5","D5"],"kind":"white"},{"cells":["C6","C7"],"kind":"white"},{"cells":["D1","E1"],"kind":"white"},{"cells":["D3","D4"],"kind":"white"},{"cells":["D4","D5"],"kind":"white"},{"cells":["D4","E4"],"kind":"white"},{"cells":["D6","E6"],"kind":"white"},{"cells":["D8","E8"],"kind":"black"},{"cells":["E7","E8"],"kind":"white"},{"cells":["E8","E9"],"kind":"white"},{"cells":["F1","F2"],"kind":"white"},{"cells":["F1","G1"],"kind":"white"},{"cells":["F2","G2"],"kind":"black"},{"cells":["F5","F6"],"kind":"white"},{"cells":["F6","G6"],"kind":"white"},{"cells":["G2","G3"],"kind":"white"},{"cells":["G2","H2"],"kind":"white"},{"cells":["G5","G6"],"kind":"white"},{"cells":["G7","H7"],"kind":"white"},{"cells":["G8","H8"],"kind":"white"},{"cells":["H2","I2"],"kind":"white"},{"cells":["H3","H4"],"kind":"white"},{"cells":["H3","I3"],"kind":"white"},{"cells":["H8","H9"],"kind":"black"},{"cells":["H8","I8"],"kind":"white"},{"cells":["H9","I9"],"kind":"white"},{"cells":["I3","I4"],"kind":"black"},{"cells":["I4","I5"],"kind":"white"}]`
+
+func TestEdgeConstraints(t *testing.T) {
+	Convey("Given an empty grid and a solver", t, func() {
+		blankGrid := strings.Repeat(".", 81)
+		lenient := solver.WithValidation(solver.LenientValidation)
+
+		solve := func(cs ...solver.Constraint) (map[string]string, error) {
+			return solver.SolveContext(context.Background(), blankGrid, lenient, solver.WithConstraints(cs...))
+		}
+		value := func(values map[string]string, square string) int {
+			return int(values[square][0] - '0')
+		}
+
+		Convey("When SolveContext is called with every dot of a Kropki sudoku", func() {
+			var edges []solver.Edge
+			So(json.Unmarshal([]byte(kropkiEdges), &edges), ShouldBeNil)
+			kropki := solver.Edges{Edges: edges, Negative: []solver.EdgeKind{solver.WhiteDot, solver.BlackDot}}
+
+			values, err := solve(kropki)
+			unique, _ := solver.IsUnique(blankGrid, lenient, solver.WithConstraints(kropki))
+
+			Convey("Then show the solved sudoku, unique without any clue", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values), ShouldEqual, killerSolution)
+				So(unique, ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveContext is called with dots, X, V and greater-than signs", func() {
+			values, err := solve(solver.Edges{Edges: []solver.Edge{
+				{Cells: [2]string{"A1", "A2"}, Kind: solver.WhiteDot},
+				{Cells: [2]string{"B1", "B2"}, Kind: solver.BlackDot},
+				{Cells: [2]string{"C1", "C2"}, Kind: solver.EdgeX},
+				{Cells: [2]string{"D1", "D2"}, Kind: solver.EdgeV},
+				{Cells: [2]string{"E2", "E1"}, Kind: solver.GreaterThan},
+				{Cells: [2]string{"E2", "F2"}, Kind: solver.GreaterThan},
+			}})
+
+			Convey("Then the values of each edge hold its relation", func() {
+				So(err, ShouldBeNil)
+				So(value(values, "A1")-value(values, "A2"), ShouldBeIn, []int{-1, 1})
+				So([]int{value(values, "B1"), value(values, "B2")}, ShouldBeIn, [][]int{{1, 2}, {2, 1}, {2, 4}, {4, 2}, {3, 6}, {6, 3}, {4, 8}, {8, 4}})
+				So(value(values, "C1")+value(values, "C2"), ShouldEqual, 10)
+				So(value(values, "D1")+value(values, "D2"), ShouldEqual, 5)
+				So(value(values, "E2"), ShouldBeGreaterThan, value(values, "E1"))
+				So(value(values, "E2"), ShouldBeGreaterThan, value(values, "F2"))
+			})
+		})
+
+		Convey("When SolveContext is called with the negative XV constraint and no edge", func() {
+			values, err := solve(solver.Edges{Negative: []solver.EdgeKind{solver.EdgeX, solver.EdgeV}})
+
+			Convey("Then no adjacent squares add up to 5 or 10", func() {
+				So(err, ShouldBeNil)
+				sol := solver.Classic.Flatten(values)
+				for s := range sol {
+					for _, t := range []int{s + 1, s + 9} {
+						if t < 81 && (t != s+1 || t%9 != 0) {
+							So(int(sol[s]-'0')+int(sol[t]-'0'), ShouldNotBeIn, []int{5, 10})
+						}
+					}
+				}
+			})
+		})
+
+		Convey("When SolveContext is called with the negative white dots and a greater-than sign from a 2", func() {
+			_, err := solver.SolveContext(context.Background(), "2"+blankGrid[1:], lenient, solver.WithConstraints(solver.Edges{
+				Edges:    []solver.Edge{{Cells: [2]string{"A1", "A2"}, Kind: solver.GreaterThan}},
+				Negative: []solver.EdgeKind{solver.WhiteDot},
+			}))
+
+			Convey("Then return ErrNoSolution, the 1 left to the sign being consecutive", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
+			})
+		})
+
+		Convey("When SolveContext is called with greater-than signs going round in a loop", func() {
+			_, err := solve(solver.Edges{Edges: []solver.Edge{
+				{Cells: [2]string{"A1", "A2"}, Kind: solver.GreaterThan},
+				{Cells: [2]string{"A2", "B2"}, Kind: solver.GreaterThan},
+				{Cells: [2]string{"B2", "B1"}, Kind: solver.GreaterThan},
+				{Cells: [2]string{"B1", "A1"}, Kind: solver.GreaterThan},
+			}})
+
+			Convey("Then return ErrNoSolution", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
+			})
+		})
+
+		Convey("When SolveContext is called with edges which are not valid", func() {
+			_, errKind := solve(solver.Edges{Edges: []solver.Edge{{Cells: [2]string{"A1", "A2"}, Kind: "grey"}}})
+			_, errSquare := solve(solver.Edges{Edges: []solver.Edge{{Cells: [2]string{"A1", "A0"}, Kind: solver.EdgeX}}})
+			_, errAdjacent := solve(solver.Edges{Edges: []solver.Edge{
+				{Cells: [2]string{"A1", "A2"}, Kind: solver.EdgeX},
+				{Cells: [2]string{"A1", "B2"}, Kind: solver.EdgeV},
+			}})
+			_, errNegative := solve(solver.Edges{Negative: []solver.EdgeKind{solver.GreaterThan}})
+			_, errUnknown := solve(solver.Edges{Negative: []solver.EdgeKind{"foo"}})
+
+			Convey("Then return an EdgeError naming the faulty edge", func() {
+				var edgeErr *solver.EdgeError
+				So(errors.As(errKind, &edgeErr), ShouldBeTrue)
+				So(*edgeErr, ShouldResemble, solver.EdgeError{Edge: 0, Kind: "grey"})
+				So(errors.As(errSquare, &edgeErr), ShouldBeTrue)
+				So(*edgeErr, ShouldResemble, solver.EdgeError{Edge: 0, Cell: "A0"})
+				So(errors.As(errAdjacent, &edgeErr), ShouldBeTrue)
+				So(*edgeErr, ShouldResemble, solver.EdgeError{Edge: 1, Cells: []string{"A1", "B2"}})
+				So(errors.As(errNegative, &edgeErr), ShouldBeTrue)
+				So(*edgeErr, ShouldResemble, solver.EdgeError{Edge: -1, Kind: ">"})
+				So(errors.As(errUnknown, &edgeErr), ShouldBeTrue)
+				So(errUnknown.Error(), ShouldEqual, `Invalid negative constraint: unknown kind "foo"`)
+			})
+		})
+	})
+}
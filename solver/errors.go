@@ -0,0 +1,195 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoSolution is returned when the sudoku is consistent but every branch of the search dead-ends
+var ErrNoSolution = errors.New("The sudoku has no solution")
+
+// ErrBudgetExceeded is returned when the search stops after exploring the maximum number of nodes allowed
+var ErrBudgetExceeded = errors.New("The search budget was exceeded before a solution was found")
+
+// ErrStuck is returned when the logical solver finds no technique to go further
+var ErrStuck = errors.New("No technique applies, the sudoku can not be solved without guessing")
+
+// GridSizeError is returned when the grid does not have one character per square
+type GridSizeError struct {
+	Expected int `json:"expected"`
+	Size     int `json:"size"`
+}
+
+func (e *GridSizeError) Error() string {
+	return fmt.Sprintf("Invalid grid size: expected grid size of %d found grid size of %d", e.Expected, e.Size)
+}
+
+// TooFewCluesError is returned when the grid does not give enough clues, or not enough different digits
+type TooFewCluesError struct {
+	Clues     int `json:"clues"`
+	MinClues  int `json:"min_clues"`
+	Digits    int `json:"digits"`
+	MinDigits int `json:"min_digits"`
+}
+
+func (e *TooFewCluesError) Error() string {
+	if e.Clues < e.MinClues {
+		return fmt.Sprintf("Invalid number of squares filled: expected a minimum of %d clues found %d", e.MinClues, e.Clues)
+	}
+	return fmt.Sprintf("Invalid number of different clues digits: expected a minimum of %d different digits found %d", e.MinDigits, e.Digits)
+}
+
+// InvalidCharacterError is returned when the grid contains a character which is neither a symbol nor an empty square
+type InvalidCharacterError struct {
+	// Index is the position of the character, or of the token, in the grid
+	Index int    `json:"index"`
+	Char  string `json:"char"`
+}
+
+func (e *InvalidCharacterError) Error() string {
+	return fmt.Sprintf("Invalid character %q at index %d: expected a symbol of the grid, '0' or '.'", e.Char, e.Index)
+}
+
+// ConflictError is returned when the same digit is given more than once in a unit
+type ConflictError struct {
+	Cells []string `json:"cells"`
+	Digit string   `json:"digit"`
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("The sudoku contains errors and can not be solved: digit %s is given in %s", e.Digit, strings.Join(e.Cells, " and "))
+}
+
+// UnknownVariantError is returned when the variant asked for does not exist
+type UnknownVariantError struct {
+	Variant string `json:"variant"`
+}
+
+func (e *UnknownVariantError) Error() string {
+	names := make([]string, len(variants))
+	for i, vc := range variants {
+		names[i] = string(vc.variant)
+	}
+	last := len(names) - 1
+	return fmt.Sprintf("Unknown variant %q: expected %s or %s, or several of them joined with +", e.Variant, strings.Join(names[:last], ", "), names[last])
+}
+
+// UnknownLayoutError is returned when the multi-grid layout asked for does not exist
+type UnknownLayoutError struct {
+	Layout string `json:"layout"`
+}
+
+func (e *UnknownLayoutError) Error() string {
+	names := make([]string, len(layouts))
+	for i, l := range layouts {
+		names[i] = l.name
+	}
+	last := len(names) - 1
+	return fmt.Sprintf("Unknown layout %q: expected %s or %s", e.Layout, strings.Join(names[:last], ", "), names[last])
+}
+
+// RegionError is returned when the region map of a jigsaw sudoku does not describe valid regions
+type RegionError struct {
+	// Region is the label of the faulty region, empty when the map does not have the size of a sudoku
+	Region string `json:"region,omitempty"`
+	// Squares is the number of squares of the region, or of the map
+	Squares  int `json:"squares"`
+	Expected int `json:"expected,omitempty"`
+	// Disconnected is set when some squares of the region can not be reached from the others
+	Disconnected bool `json:"disconnected,omitempty"`
+}
+
+func (e *RegionError) Error() string {
+	switch {
+	case e.Region == "":
+		return fmt.Sprintf("Invalid region map size: expected n*n labels for a sudoku of size n found %d", e.Squares)
+	case e.Disconnected:
+		return fmt.Sprintf("Invalid region %q: its squares are not connected", e.Region)
+	}
+	return fmt.Sprintf("Invalid region %q: expected %d squares found %d", e.Region, e.Expected, e.Squares)
+}
+
+// CageError is returned when a cage of a killer sudoku is not valid
+type CageError struct {
+	// Cage is the index of the faulty cage
+	Cage int `json:"cage"`
+	// Cell is the unknown square, or the square already in another cage
+	Cell    string `json:"cell,omitempty"`
+	Overlap bool   `json:"overlap,omitempty"`
+	// Sum and Cells are the sum and the number of squares of a cage whose sum can not be reached
+	Sum   int `json:"sum,omitempty"`
+	Cells int `json:"cells,omitempty"`
+}
+
+func (e *CageError) Error() string {
+	switch {
+	case e.Overlap:
+		return fmt.Sprintf("Invalid cage %d: square %s is already in a cage", e.Cage, e.Cell)
+	case e.Cell != "":
+		return fmt.Sprintf("Invalid cage %d: unknown square %q", e.Cage, e.Cell)
+	}
+	return fmt.Sprintf("Invalid cage %d: %d distinct digits can not add up to %d", e.Cage, e.Cells, e.Sum)
+}
+
+// SquareError is returned when the squares of a line are not valid: none is given, or one of them is not in the
+// grid, is repeated or does not touch the square before it
+type SquareError struct {
+	// Constraint is the kind of constraint naming the square: thermometer, arrow,...
+	Constraint string `json:"constraint"`
+	// Square is the faulty square, empty for a line without squares
+	Square   string `json:"square,omitempty"`
+	Repeated bool   `json:"repeated,omitempty"`
+	// Previous is the square before Square along the line when they do not touch
+	Previous string `json:"previous,omitempty"`
+}
+
+func (e *SquareError) Error() string {
+	switch {
+	case e.Square == "":
+		return fmt.Sprintf("Invalid %s: no square along the line", e.Constraint)
+	case e.Repeated:
+		return fmt.Sprintf("Invalid %s: square %s is repeated", e.Constraint, e.Square)
+	case e.Previous != "":
+		return fmt.Sprintf("Invalid %s: squares %s and %s do not touch", e.Constraint, e.Previous, e.Square)
+	}
+	return fmt.Sprintf("Invalid %s: unknown square %q", e.Constraint, e.Square)
+}
+
+// EdgeError is returned when an edge of a dot or border sudoku is not valid
+type EdgeError struct {
+	// Edge is the index of the faulty edge, -1 for a kind of edge of the negative constraint which is unknown or
+	// can not be negated
+	Edge int `json:"edge"`
+	// Cell is the unknown square
+	Cell string `json:"cell,omitempty"`
+	// Cells are the squares of an edge which are not adjacent
+	Cells []string `json:"cells,omitempty"`
+	// Kind is the unknown kind of edge, or the kind which can not be negated
+	Kind string `json:"kind,omitempty"`
+}
+
+func (e *EdgeError) Error() string {
+	switch {
+	case e.Edge < 0 && edgeRelations[EdgeKind(e.Kind)] == nil:
+		return fmt.Sprintf("Invalid negative constraint: unknown kind %q", e.Kind)
+	case e.Edge < 0:
+		return fmt.Sprintf("Invalid negative constraint: the edges %q can not be negated", e.Kind)
+	case e.Kind != "":
+		return fmt.Sprintf("Invalid edge %d: unknown kind %q", e.Edge, e.Kind)
+	case e.Cell != "":
+		return fmt.Sprintf("Invalid edge %d: unknown square %q", e.Edge, e.Cell)
+	}
+	return fmt.Sprintf("Invalid edge %d: squares %s and %s are not adjacent", e.Edge, e.Cells[0], e.Cells[1])
+}
+
+// OutsideClueError is returned when a clue given outside of the grid is not valid
+type OutsideClueError struct {
+	// Clue is the faulty clue, as written in the notation of ParsePuzzle
+	Clue   string `json:"clue"`
+	Reason string `json:"reason"`
+}
+
+func (e *OutsideClueError) Error() string {
+	return fmt.Sprintf("Invalid outside clue %q: %s", e.Clue, e.Reason)
+}
@@ -0,0 +1,208 @@
+package solver
+
+import "math/bits"
+
+// fishNames holds the techniques of the fish of each size, basic, finned and sashimi
+var fishNames = map[int][3]Technique{
+	2: {XWing, FinnedXWing, SashimiXWing},
+	3: {Swordfish, FinnedSwordfish, SashimiSwordfish},
+	4: {Jellyfish, FinnedJellyfish, SashimiJellyfish},
+}
+
+// Fish finds n lines of a kind, the base, whose candidates for a digit are all in n lines of the other kind,
+// the cover: each cover line takes the digit from one of the base lines, so it is eliminated from the rest of the
+// cover lines. When finned is set, the candidates of the base outside of the cover, the fins, are allowed: the digit
+// is then only eliminated from the squares of the cover seeing every fin, as one of the fins holds the digit
+// unless the fish does. The fish is sashimi when a base line is left with a single candidate without the fins.
+func (l *logic) fish(n int, finned bool) *Step {
+	for _, kind := range []int{unitRow, unitColumn} {
+		for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+			if st := l.fishOf(n, finned, kind, d); st != nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// FishOf finds a fish of digit d whose base lines are of the kind given
+func (l *logic) fishOf(n int, finned bool, kind int, d uint32) *Step {
+	// The fins seeing a common square, they are within a box: the lines of the fish hold at most the
+	// width of a box more candidates than the fish
+	limit := n
+	if finned {
+		limit += l.g.boxRows
+		if l.g.boxCols > l.g.boxRows {
+			limit = n + l.g.boxCols
+		}
+		if l.g.irregular {
+			limit = n + l.g.size
+		}
+	}
+
+	// The base lines the digit is not placed in, with the squares which can hold it
+	var bases []int
+	var cells [][]int
+	for u, unit := range l.r.unitlist {
+		if l.kinds[u] != kind {
+			continue
+		}
+		var cs []int
+		placed := false
+		for _, s := range unit {
+			if l.values[s]&d != 0 {
+				cs = append(cs, s)
+				placed = placed || l.placed[s]
+			}
+		}
+		if placed || len(cs) < 2 || len(cs) > limit {
+			continue
+		}
+		bases = append(bases, u)
+		cells = append(cells, cs)
+	}
+	if len(bases) < n {
+		return nil
+	}
+
+	// The cover lines are the lines of the other kind going through the candidates, numbered
+	// in the order they are met. covers holds for each candidate the bitmask of its cover lines.
+	var lines []int
+	covers := map[int]uint64{}
+	for _, cs := range cells {
+		for _, s := range cs {
+			for _, v := range l.r.units[s] {
+				if l.kinds[v] != unitRow && l.kinds[v] != unitColumn || l.kinds[v] == kind {
+					continue
+				}
+				i := indexOf(lines, v)
+				if i < 0 {
+					i, lines = len(lines), append(lines, v)
+				}
+				if i < 64 {
+					covers[s] |= 1 << uint(i)
+				}
+			}
+		}
+	}
+	if len(lines) > 64 {
+		return nil
+	}
+
+	var st *Step
+	subsets(len(bases), n, func(picked []int) bool {
+		if l.stopped() {
+			return true
+		}
+		var base []int
+		var all uint64
+		for _, p := range picked {
+			for _, s := range cells[p] {
+				if contains(base, s) {
+					// The base lines must not share a square
+					return false
+				}
+				base = append(base, s)
+				all |= covers[s]
+			}
+		}
+		if !finned && bits.OnesCount64(all) < n {
+			return false
+		}
+
+		var lineBits []int
+		for i := 0; i < len(lines); i++ {
+			if all&(1<<uint(i)) != 0 {
+				lineBits = append(lineBits, i)
+			}
+		}
+		if len(lineBits) < n || len(lineBits) > limit {
+			return false
+		}
+
+		return subsets(len(lineBits), n, func(chosen []int) bool {
+			var cover uint64
+			for _, c := range chosen {
+				cover |= 1 << uint(lineBits[c])
+			}
+
+			// Each base line needs a candidate in the cover, and the fins are the candidates outside of it
+			var fins []int
+			sashimi := false
+			for _, p := range picked {
+				inside := 0
+				for _, s := range cells[p] {
+					if covers[s]&cover != 0 {
+						inside++
+					} else {
+						fins = append(fins, s)
+					}
+				}
+				if inside == 0 {
+					return false
+				}
+				sashimi = sashimi || inside == 1
+			}
+			if finned != (len(fins) > 0) {
+				return false
+			}
+
+			var targets, units []int
+			for _, p := range picked {
+				units = append(units, bases[p])
+			}
+			for _, c := range chosen {
+				units = append(units, lines[lineBits[c]])
+				for _, s := range l.r.unitlist[lines[lineBits[c]]] {
+					if l.values[s]&d == 0 || l.placed[s] || contains(base, s) || contains(targets, s) || !l.seesAll(s, fins) {
+						continue
+					}
+					targets = append(targets, s)
+				}
+			}
+			if len(targets) == 0 {
+				return false
+			}
+
+			st = l.begin(units, base, d)
+			for _, s := range fins {
+				st.Fins = append(st.Fins, l.g.squares[s])
+			}
+
+			names := fishNames[n]
+			switch {
+			case !finned:
+				st.Technique = names[0]
+			case sashimi:
+				st.Technique = names[2]
+			default:
+				st.Technique = names[1]
+			}
+			for _, s := range targets {
+				l.eliminate(s, d)
+			}
+			return true
+		})
+	})
+	return st
+}
+
+// SeesAll report whether square s is a peer of all the squares
+func (l *logic) seesAll(s int, squares []int) bool {
+	for _, s2 := range squares {
+		if s == s2 || !contains(l.r.peers[s], s2) {
+			return false
+		}
+	}
+	return true
+}
+
+// IndexOf returns the index of v in values, -1 if it is not there
+func indexOf(values []int, v int) int {
+	for i, x := range values {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
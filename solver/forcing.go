@@ -0,0 +1,286 @@
+package solver
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// forcingTechniques holds the techniques making hypotheses, used with WithForcingChains only
+var forcingTechniques = map[Technique]bool{
+	Nishio:            true,
+	DigitForcingChain: true,
+	CellForcingChain:  true,
+	UnitForcingChain:  true,
+}
+
+// Branch is a hypothesis of a forcing step, with the digits placed by propagating it until it leads to the
+// conclusion of the step or to a contradiction
+type Branch struct {
+	// Hypothesis is like "5 in A1" or "5 not in A1"
+	Hypothesis string `json:"hypothesis"`
+	// Placed holds the digits left alone in a square by the propagation, in order
+	Placed []Candidates `json:"placed,omitempty"`
+	// Conclusion is like "4 in C5", "4 not in C5" or "no candidate left in D5"
+	Conclusion string `json:"conclusion"`
+}
+
+// String explain the branch, like "5 in A1 => 3 in B2, 7 in C4 => no candidate left in D5"
+func (b Branch) String() string {
+	parts := []string{b.Hypothesis}
+	if len(b.Placed) > 0 {
+		var placed []string
+		for _, c := range b.Placed {
+			placed = append(placed, c.Digits+" in "+c.Square)
+		}
+		parts = append(parts, strings.Join(placed, ", "))
+	}
+	return strings.Join(append(parts, b.Conclusion), " => ")
+}
+
+// fact is a digit (as a bit) in a square, or not in it when in is false
+type fact struct {
+	s  int
+	d  uint32
+	in bool
+}
+
+// Holds report whether the fact is true on the board
+func (f fact) holds(values board) bool {
+	if f.in {
+		return values[f.s] == f.d
+	}
+	return values[f.s]&f.d == 0
+}
+
+// FactString name a fact, like "5 in A1" or "5 not in A1"
+func (l *logic) factString(f fact) string {
+	if f.in {
+		return digitString(f.d) + " in " + l.g.squares[f.s]
+	}
+	return digitString(f.d) + " not in " + l.g.squares[f.s]
+}
+
+// Assume propagate hypothesis h on a copy of the board with assign and eliminate.
+// Return the board and false if a contradiction is detected.
+func (l *logic) assume(h fact) (board, bool) {
+	values := l.values.clone()
+	if h.in {
+		return values, l.p.assign(values, h.s, h.d)
+	}
+	return values, l.p.eliminate(values, h.s, h.d, ByAssignment)
+}
+
+// trail records the digits left alone in a square while a hypothesis is propagated, until a fact holds
+type trail struct {
+	NopObserver
+	g      *Geometry
+	values board
+	until  func(values board) bool
+	done   bool
+	placed []Candidates
+}
+
+// OnEliminate replay the elimination on the board of the trail
+func (t *trail) OnEliminate(square, digit string, reason Reason) {
+	if t.done {
+		return
+	}
+	s := t.g.index[square]
+	before := t.values[s]
+	t.values[s] &^= 1 << uint(t.g.value(digit)-1)
+	if t.until != nil && t.until(t.values) {
+		t.done = true
+		return
+	}
+	if bits.OnesCount32(before) > 1 && bits.OnesCount32(t.values[s]) == 1 {
+		t.placed = append(t.placed, Candidates{Square: square, Digits: digitString(t.values[s])})
+	}
+}
+
+// Explain propagate hypothesis h again to record its branch: until fact f holds, or up to the contradiction
+// found on values when ok is false
+func (l *logic) explain(h, f fact, values board, ok bool) Branch {
+	t := &trail{g: l.g, values: l.values.clone()}
+	if h.in {
+		t.values[h.s] = h.d
+	}
+	if ok {
+		t.until = f.holds
+	}
+	l.p.observer = t
+	l.assume(h)
+	l.p.observer = nil
+
+	b := Branch{Hypothesis: l.factString(h), Placed: t.placed, Conclusion: l.factString(f)}
+	if !ok {
+		b.Conclusion = l.contradiction(values)
+	}
+	return b
+}
+
+// Contradiction tells why a board can not be solved, like "no candidate left in D5" or "no place left for 4 in
+// row C". The constraints pruning the candidates in other ways are reported as broken.
+func (l *logic) contradiction(values board) string {
+	for s, v := range values {
+		if v == 0 {
+			return "no candidate left in " + l.g.squares[s]
+		}
+	}
+	for u, unit := range l.r.unitlist {
+		var all uint32
+		for _, s := range unit {
+			all |= values[s]
+		}
+		if missing := l.g.allDigits &^ all; missing != 0 {
+			return "no place left for " + digitString(missing&-missing) + " in " + l.names[u]
+		}
+	}
+	return "a constraint is broken"
+}
+
+// Force propagate the hypotheses, one of which is true, and find a fact not known yet they all lead to outside of
+// their squares, placements first, the hypotheses leading to a contradiction being left out. The fact is applied
+// and returned with a step based on the digits of squares in units, explaining each branch. Return nil if there
+// is none.
+func (l *logic) force(hyps []fact, units []int, squares []int, digits uint32) *Step {
+	boards := make([]board, len(hyps))
+	oks := make([]bool, len(hyps))
+	var live []board
+	for i, h := range hyps {
+		if l.stopped() {
+			return nil
+		}
+		boards[i], oks[i] = l.assume(h)
+		if oks[i] {
+			live = append(live, boards[i])
+		}
+	}
+	f, found := l.common(live, squares)
+	if !found {
+		return nil
+	}
+
+	st := l.begin(units, squares, digits)
+	for i, h := range hyps {
+		st.Branches = append(st.Branches, l.explain(h, f, boards[i], oks[i]))
+	}
+	if f.in {
+		l.place(f.s, f.d)
+	} else {
+		l.eliminate(f.s, f.d)
+	}
+	return st
+}
+
+// Common finds the first fact not known yet which holds on all the boards, placements first, the squares given
+// being left out. Return false if there is none.
+func (l *logic) common(boards []board, squares []int) (fact, bool) {
+	if len(boards) == 0 {
+		return fact{}, false
+	}
+	for s, v := range boards[0] {
+		if l.placed[s] || bits.OnesCount32(v) != 1 || contains(squares, s) {
+			continue
+		}
+		f, all := fact{s, v, true}, true
+		for _, b := range boards[1:] {
+			all = all && f.holds(b)
+		}
+		if all {
+			return f, true
+		}
+	}
+
+	for s, v := range l.values {
+		if l.placed[s] || contains(squares, s) {
+			continue
+		}
+		for _, b := range boards {
+			v &^= b[s]
+		}
+		if v != 0 {
+			return fact{s, v & -v, false}, true
+		}
+	}
+	return fact{}, false
+}
+
+// CellForcingChain finds a square whose candidates all lead to the same fact once placed
+func (l *logic) cellForcingChain() *Step {
+	for s, v := range l.values {
+		if l.placed[s] {
+			continue
+		}
+		var hyps []fact
+		for x := v; x != 0; x &= x - 1 {
+			hyps = append(hyps, fact{s, x & -x, true})
+		}
+		if st := l.force(hyps, nil, []int{s}, v); st != nil {
+			return st
+		}
+	}
+	return nil
+}
+
+// UnitForcingChain finds a digit whose places in a unit all lead to the same fact once it is placed there
+func (l *logic) unitForcingChain() *Step {
+	for u, unit := range l.r.unitlist {
+		for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+			var hyps []fact
+			var squares []int
+			placed := false
+			for _, s := range unit {
+				if l.values[s]&d != 0 {
+					hyps = append(hyps, fact{s, d, true})
+					squares = append(squares, s)
+					placed = placed || l.placed[s]
+				}
+			}
+			if placed || len(hyps) < 2 {
+				continue
+			}
+			if st := l.force(hyps, []int{u}, squares, d); st != nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// DigitForcingChain finds a candidate leading to the same fact whether it is placed or eliminated
+func (l *logic) digitForcingChain() *Step {
+	for s, v := range l.values {
+		if l.placed[s] {
+			continue
+		}
+		for x := v; x != 0; x &= x - 1 {
+			d := x & -x
+			if st := l.force([]fact{{s, d, true}, {s, d, false}}, nil, []int{s}, d); st != nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// Nishio finds a candidate whose placement leads to a contradiction: it is eliminated
+func (l *logic) nishio() *Step {
+	for s, v := range l.values {
+		if l.placed[s] {
+			continue
+		}
+		if l.stopped() {
+			return nil
+		}
+		for x := v; x != 0; x &= x - 1 {
+			h := fact{s, x & -x, true}
+			if values, ok := l.assume(h); !ok {
+				st := l.begin(nil, []int{s}, h.d)
+				st.Branches = []Branch{l.explain(h, fact{}, values, false)}
+				l.eliminate(s, h.d)
+				return st
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,560 @@
+package solver
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// symbols holds the values of the squares, in order. Sudokus bigger than 9x9 use letters after 9.
+	symbols = "123456789ABCDEFGHIJKLMNOP"
+	// rowNames holds the names of the rows, in order
+	rowNames = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// maxSize is the size of the biggest sudoku supported, every value must fit in a uint32 bitmask
+	maxSize = len(symbols)
+)
+
+// Geometry describes the shape of a sudoku: its size and the dimensions of its boxes.
+// A sudoku of size n has n rows, n columns and n symbols, and its boxes have boxRows*boxCols = n squares.
+// Squares are named after their row, a letter, and their column, a number: A1, B5, D8,...
+// A multi-grid sudoku, like the Samurai, is made of several grids laid out on a bigger board and sharing boxes,
+// its squares being named after their row and column on that board.
+type Geometry struct {
+	size    int
+	boxRows int
+	boxCols int
+	// irregular is set when the boxes are replaced by the regions of a jigsaw sudoku
+	irregular bool
+
+	// rows and cols are the dimensions of the board the grids are laid out on
+	rows, cols int
+	// grids holds the position on the board, row and column, of the top left square of each grid
+	grids [][2]int
+	// layout holds the index of the square at each position of the board, row by row, -1 between the grids
+	layout []int
+	// positions holds the position on the board of each square
+	positions []int
+
+	// squares holds the names of the squares, indexed row by row
+	squares []string
+	// index holds the index of each square from its name
+	index map[string]int
+	// unitlist holds the squares of the columns, rows and boxes of the sudoku
+	unitlist [][]int
+	// allDigits has a bit set for each of the symbols
+	allDigits uint32
+	// rules holds the propagation tables of the sudokus having no constraint other than ClassicUnits
+	rules *rules
+}
+
+// Classic is the geometry of the 9x9 sudoku made of 3x3 boxes
+var Classic = mustGeometry(3, 3)
+
+var (
+	// Samurai is the geometry of the Samurai sudoku: four grids at the corners of a 21x21 board, each one sharing
+	// a corner box with a fifth grid in the middle
+	Samurai = mustMultiGridGeometry([][2]int{{0, 0}, {0, 12}, {6, 6}, {12, 0}, {12, 12}})
+	// Twodoku is the geometry of the twin sudoku: two grids sharing a corner box
+	Twodoku = mustMultiGridGeometry([][2]int{{0, 0}, {6, 6}})
+	// Butterfly is the geometry of the butterfly sudoku: four grids overlapping on a 12x12 board
+	Butterfly = mustMultiGridGeometry([][2]int{{0, 0}, {0, 3}, {3, 0}, {3, 3}})
+)
+
+// layouts holds the multi-grid geometries by name, in the order they are listed in the errors
+var layouts = []struct {
+	name     string
+	geometry *Geometry
+}{
+	{"samurai", Samurai},
+	{"twodoku", Twodoku},
+	{"butterfly", Butterfly},
+}
+
+// LayoutGeometry returns the multi-grid geometry named samurai, twodoku or butterfly.
+// Return an *UnknownLayoutError if there is no such layout.
+func LayoutGeometry(name string) (*Geometry, error) {
+	for _, l := range layouts {
+		if l.name == strings.ToLower(name) {
+			return l.geometry, nil
+		}
+	}
+	return nil, &UnknownLayoutError{Layout: name}
+}
+
+// NewGeometry create the geometry of a sudoku made of boxes of boxRows rows and boxCols columns, e.g.
+// 2x2 boxes for a 4x4 sudoku, 2x3 boxes for a 6x6 one, 3x4 for a 12x12 one and 4x4 for a 16x16 one.
+func NewGeometry(boxRows, boxCols int) (*Geometry, error) {
+	size := boxRows * boxCols
+	if boxRows < 1 || boxCols < 1 || size < 2 || size > maxSize {
+		return nil, fmt.Errorf("Invalid box dimensions: expected boxes of 2 to %d squares found %dx%d", maxSize, boxRows, boxCols)
+	}
+
+	return newGeometry(boxRows, boxCols, nil, [][2]int{{0, 0}})
+}
+
+// NewJigsawGeometry create the geometry of a jigsaw sudoku, whose boxes are replaced by irregular regions.
+// The region map labels the region of each square, row by row, with one character: a sudoku of size n has
+// n regions of n connected squares. Return a *RegionError if the map does not describe such regions.
+func NewJigsawGeometry(regions string) (*Geometry, error) {
+	labels := []rune(regions)
+	size := int(math.Sqrt(float64(len(labels))))
+	if size*size != len(labels) || size < 2 || size > maxSize {
+		return nil, &RegionError{Squares: len(labels)}
+	}
+
+	units, err := createRegions(size, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	// The boxes are kept as close to squares as possible to lay out the windows of a jigsaw windoku
+	boxRows := int(math.Sqrt(float64(size)))
+	for size%boxRows != 0 {
+		boxRows--
+	}
+
+	return newGeometry(boxRows, size/boxRows, units, [][2]int{{0, 0}})
+}
+
+// NewMultiGridGeometry create the geometry of a sudoku made of classic 9x9 grids overlapping on some of their
+// boxes, like the Samurai. Each grid is given by the position of its top left square on the board, its row and
+// its column counted from 0, which must fall on the boxes of the other grids. Every grid keeps its own rows,
+// columns and boxes, the squares shared by several grids being peers in all of them.
+func NewMultiGridGeometry(grids [][2]int) (*Geometry, error) {
+	if len(grids) == 0 {
+		return nil, fmt.Errorf("Invalid multi-grid layout: expected at least one grid")
+	}
+
+	seen := map[[2]int]bool{}
+	for _, o := range grids {
+		if o[0] < 0 || o[1] < 0 || o[0]%Classic.boxRows != 0 || o[1]%Classic.boxCols != 0 {
+			return nil, fmt.Errorf("Invalid multi-grid layout: grid at %v is not aligned on the boxes", o)
+		}
+		if o[0]+Classic.size > len(rowNames) {
+			return nil, fmt.Errorf("Invalid multi-grid layout: grid at %v is beyond row %s", o, rowNames[len(rowNames)-1:])
+		}
+		if seen[o] {
+			return nil, fmt.Errorf("Invalid multi-grid layout: two grids are at %v", o)
+		}
+		seen[o] = true
+	}
+
+	return newGeometry(Classic.boxRows, Classic.boxCols, nil, append([][2]int(nil), grids...))
+}
+
+// newGeometry create a geometry made of grids laid out at the given positions, their boxes being replaced by
+// regions when given
+func newGeometry(boxRows, boxCols int, regions [][]int, grids [][2]int) (*Geometry, error) {
+	size := boxRows * boxCols
+	g := &Geometry{
+		size:      size,
+		boxRows:   boxRows,
+		boxCols:   boxCols,
+		grids:     grids,
+		allDigits: 1<<uint(size) - 1,
+	}
+
+	for _, o := range grids {
+		if o[0]+size > g.rows {
+			g.rows = o[0] + size
+		}
+		if o[1]+size > g.cols {
+			g.cols = o[1] + size
+		}
+	}
+
+	g.layout = make([]int, g.rows*g.cols)
+	for p := range g.layout {
+		g.layout[p] = -1
+	}
+	for _, o := range grids {
+		for r := o[0]; r < o[0]+size; r++ {
+			for c := o[1]; c < o[1]+size; c++ {
+				g.layout[r*g.cols+c] = 0
+			}
+		}
+	}
+
+	g.index = make(map[string]int, len(g.layout))
+	for p := range g.layout {
+		if g.layout[p] < 0 {
+			continue
+		}
+		g.layout[p] = len(g.squares)
+		g.positions = append(g.positions, p)
+		name := rowNames[p/g.cols:p/g.cols+1] + strconv.Itoa(p%g.cols+1)
+		g.index[name] = len(g.squares)
+		g.squares = append(g.squares, name)
+	}
+
+	// The units of a grid are found on the board, the boxes shared by several grids are listed once
+	shared := map[string]bool{}
+	for _, o := range grids {
+		for _, unit := range createUnitList(size, boxRows, boxCols) {
+			for i, s := range unit {
+				unit[i] = g.at(o[0]+s/size, o[1]+s%size)
+			}
+			if key := fmt.Sprint(unit); !shared[key] {
+				shared[key] = true
+				g.unitlist = append(g.unitlist, unit)
+			}
+		}
+	}
+	if regions != nil {
+		// The boxes come last in the unit list
+		copy(g.unitlist[2*size:], regions)
+		g.irregular = true
+	}
+
+	r, err := newRules(g, nil)
+	if err != nil {
+		return nil, err
+	}
+	g.rules = r
+
+	return g, nil
+}
+
+// mustGeometry create a geometry known to be valid
+func mustGeometry(boxRows, boxCols int) *Geometry {
+	g, err := NewGeometry(boxRows, boxCols)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// mustMultiGridGeometry create a multi-grid geometry known to be valid
+func mustMultiGridGeometry(grids [][2]int) *Geometry {
+	g, err := NewMultiGridGeometry(grids)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Size returns the number of rows, columns and symbols of the sudoku, or of each of its grids
+func (g *Geometry) Size() int {
+	return g.size
+}
+
+// Squares returns the names of the squares, row by row
+func (g *Geometry) Squares() []string {
+	return append([]string(nil), g.squares...)
+}
+
+// SquareIndex returns the index of the square from its name (A1, b5,...), -1 if there is no such square
+func (g *Geometry) SquareIndex(name string) int {
+	if s, ok := g.index[strings.ToUpper(name)]; ok {
+		return s
+	}
+	return -1
+}
+
+// At returns the index of the square at row r and column c of the board, -1 if there is no such square
+func (g *Geometry) at(r, c int) int {
+	if r < 0 || r >= g.rows || c < 0 || c >= g.cols {
+		return -1
+	}
+	return g.layout[r*g.cols+c]
+}
+
+// Position returns the row and the column of square s on the board
+func (g *Geometry) position(s int) (int, int) {
+	return g.positions[s] / g.cols, g.positions[s] % g.cols
+}
+
+// Symbols returns the values a square can take, in order
+func (g *Geometry) Symbols() string {
+	return symbols[:g.size]
+}
+
+// CreateUnitList list the squares of the columns, rows and boxes of the sudoku
+func createUnitList(size, boxRows, boxCols int) [][]int {
+	res := make([][]int, 0, size*3)
+
+	for i := 0; i < size; i++ {
+		// A1 B1 C1 D1 E1 F1 G1 H1 I1...
+		col := make([]int, size)
+		for j := range col {
+			col[j] = j*size + i
+		}
+		res = append(res, col)
+	}
+
+	for i := 0; i < size; i++ {
+		// A1 A2 A3 A4 A5 A6 A7 A8 A9...
+		row := make([]int, size)
+		for j := range row {
+			row[j] = i*size + j
+		}
+		res = append(res, row)
+	}
+
+	// The boxes are laid out on boxCols rows of boxRows boxes
+	for i := 0; i < size; i++ {
+		// A1 A2 A3 B1 B2 B3 C1 C2 C3...
+		box := make([]int, size)
+		top, left := i/boxRows*boxRows, i%boxRows*boxCols
+		for j := range box {
+			box[j] = (top+j/boxCols)*size + left + j%boxCols
+		}
+		res = append(res, box)
+	}
+
+	return res
+}
+
+// CreateRegions list the squares of each region of a jigsaw sudoku, in the order the labels first appear.
+// Return a *RegionError if a region does not have size squares or if its squares are not connected.
+func createRegions(size int, labels []rune) ([][]int, error) {
+	var res [][]int
+	index := map[rune]int{}
+	for s, l := range labels {
+		i, ok := index[l]
+		if !ok {
+			i = len(res)
+			index[l] = i
+			res = append(res, nil)
+		}
+		res[i] = append(res[i], s)
+	}
+
+	for _, region := range res {
+		label := string(labels[region[0]])
+		if len(region) != size {
+			return nil, &RegionError{Region: label, Squares: len(region), Expected: size}
+		}
+
+		// Walk through the region from its first square, moving to the next square on the
+		// same row or column; every square must be reached
+		seen := map[int]bool{region[0]: true}
+		for todo := []int{region[0]}; len(todo) > 0; {
+			s := todo[len(todo)-1]
+			todo = todo[:len(todo)-1]
+			r, c := s/size, s%size
+			for _, n := range [][2]int{{r - 1, c}, {r + 1, c}, {r, c - 1}, {r, c + 1}} {
+				s2 := n[0]*size + n[1]
+				if n[0] >= 0 && n[0] < size && n[1] >= 0 && n[1] < size && !seen[s2] && labels[s2] == labels[s] {
+					seen[s2] = true
+					todo = append(todo, s2)
+				}
+			}
+		}
+		if len(seen) != size {
+			return nil, &RegionError{Region: label, Squares: len(region), Expected: size, Disconnected: true}
+		}
+	}
+
+	return res, nil
+}
+
+// CreateUnits find the indexes of the units of each squares
+func createUnits(nbSquares int, unitList [][]int) [][]int {
+	res := make([][]int, nbSquares)
+
+	for u, unit := range unitList {
+		for _, s := range unit {
+			res[s] = append(res[s], u)
+		}
+	}
+
+	return res
+}
+
+// CreatePeers find the peers of each square, the squares sharing at least one unit with it or paired with it
+func createPeers(unitList [][]int, units [][]int, pairs [][2]int) [][]int {
+	res := make([][]int, len(units))
+	seen := make([]map[int]bool, len(units))
+
+	add := func(s, s2 int) {
+		if !seen[s][s2] {
+			seen[s][s2] = true
+			res[s] = append(res[s], s2)
+		}
+	}
+
+	for s, ul := range units {
+		seen[s] = map[int]bool{s: true}
+		for _, u := range ul {
+			for _, su := range unitList[u] {
+				add(s, su)
+			}
+		}
+	}
+
+	for _, p := range pairs {
+		add(p[0], p[1])
+		add(p[1], p[0])
+	}
+
+	return res
+}
+
+// Tokens split a grid into the values of its squares. A grid containing spaces or commas is made of
+// tokens separated by them (e.g. "10 . 3 16"), otherwise each character is the value of a square.
+func tokens(grid string) []string {
+	isSeparator := func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}
+
+	if strings.IndexFunc(grid, isSeparator) >= 0 {
+		return strings.FieldsFunc(grid, isSeparator)
+	}
+
+	res := make([]string, 0, len(grid))
+	for _, c := range grid {
+		res = append(res, string(c))
+	}
+	return res
+}
+
+// Value convert a token to the value of a square, 0 being an empty square.
+// Symbols are case insensitive and values above 9 can also be written as numbers. Return -1 for an invalid token.
+func (g *Geometry) value(token string) int {
+	if token == "0" || token == "." {
+		return 0
+	}
+	if len(token) == 1 {
+		if i := strings.Index(g.Symbols(), strings.ToUpper(token)); i >= 0 {
+			return i + 1
+		}
+	}
+	if n, err := strconv.Atoi(token); err == nil && n >= 1 && n <= g.size {
+		return n
+	}
+	return -1
+}
+
+// GridValues match all the sudoku values to its square, 0 being an empty square.
+// The clues given must satisfy the validation policy.
+func (g *Geometry) gridValues(grid string, policy ValidationPolicy) ([]int, error) {
+	// The clues written after the grid in the compact notation are only read by ParsePuzzle
+	if i := strings.Index(grid, "|"); i >= 0 {
+		return nil, &OutsideClueError{Clue: grid[i+1:], Reason: "the clues after the grid must be read with ParsePuzzle"}
+	}
+
+	tks := tokens(grid)
+	if len(tks) != len(g.squares) {
+		return nil, &GridSizeError{Expected: len(g.squares), Size: len(tks)}
+	}
+
+	values := make([]int, len(tks))
+
+	// The number of clues given in the grid
+	nbClues := 0
+	var diffDigits uint32
+
+	// For each square
+	for i, t := range tks {
+		// Valid that the square value is one of the symbols ('0' or '.' for empties)
+		// and add it to the sudoku list of values.
+		v := g.value(t)
+		if v < 0 {
+			return nil, &InvalidCharacterError{Index: i, Char: t}
+		}
+
+		values[i] = v
+		if v > 0 {
+			nbClues++
+			diffDigits |= 1 << uint(v-1)
+		}
+	}
+
+	if nbClues == 0 && policy.AllowEmpty {
+		return values, nil
+	}
+	if n := bits.OnesCount32(diffDigits); nbClues < policy.MinClues || n < policy.MinDigits {
+		return nil, &TooFewCluesError{Clues: nbClues, MinClues: policy.MinClues, Digits: n, MinDigits: policy.MinDigits}
+	}
+
+	return values, nil
+}
+
+// DefaultPolicy returns the validation policy applied when none is set: StrictValidation for classic
+// sudokus, otherwise all the symbols but one must be given for a sudoku to have a unique solution.
+func (g *Geometry) defaultPolicy() ValidationPolicy {
+	if g.size == Classic.size {
+		return StrictValidation
+	}
+	return ValidationPolicy{MinDigits: g.size - 1}
+}
+
+// Digit convert a value (as a bit) to its symbol
+func (g *Geometry) digit(d uint32) string {
+	i := bits.TrailingZeros32(d)
+	return symbols[i : i+1]
+}
+
+// ToMap convert the board to a dict of possible values, {square: symbols}
+func (g *Geometry) toMap(values board) map[string]string {
+	res := make(map[string]string, len(values))
+	var sb strings.Builder
+	for s, v := range values {
+		sb.Reset()
+		for ; v != 0; v &= v - 1 {
+			sb.WriteByte(symbols[bits.TrailingZeros32(v)])
+		}
+		res[g.squares[s]] = sb.String()
+	}
+	return res
+}
+
+// Flatten convert a solved sudoku to a string of values, row by row
+func (g *Geometry) Flatten(values map[string]string) string {
+	var sb strings.Builder
+	for _, s := range g.squares {
+		sb.WriteString(values[s])
+	}
+	return sb.String()
+}
+
+// Display the solved sudoku, the boxes of a jigsaw sudoku being irregular they are not drawn.
+// The grids of a multi-grid sudoku are drawn on their board, without the boxes.
+func (g *Geometry) Display(values map[string]string) {
+	if len(g.grids) > 1 {
+		for r := 0; r < g.rows; r++ {
+			for c := 0; c < g.cols; c++ {
+				if s := g.at(r, c); s >= 0 {
+					fmt.Printf("%v ", values[g.squares[s]])
+				} else {
+					fmt.Printf("  ")
+				}
+			}
+			fmt.Println()
+		}
+		return
+	}
+
+	if g.irregular {
+		for r := 0; r < g.size; r++ {
+			for c := 0; c < g.size; c++ {
+				fmt.Printf("%v ", values[g.squares[r*g.size+c]])
+			}
+			fmt.Println()
+		}
+		return
+	}
+
+	line := strings.Repeat("-", 2*g.boxCols)
+	for i := 1; i < g.size/g.boxCols; i++ {
+		line += "+" + strings.Repeat("-", 2*g.boxCols+1)
+	}
+
+	for r := 0; r < g.size; r++ {
+		for c := 0; c < g.size; c++ {
+			if c > 0 && c%g.boxCols == 0 {
+				fmt.Printf("| ")
+			}
+			fmt.Printf("%v ", values[g.squares[r*g.size+c]])
+		}
+		fmt.Println()
+		if r < g.size-1 && (r+1)%g.boxRows == 0 {
+			fmt.Println(line)
+		}
+	}
+}
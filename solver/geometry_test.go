@@ -0,0 +1,311 @@
+package solver_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const grid4x4 = "..3..4...1....2."
+const solution4x4 = "1234341221434321"
+
+const grid6x6 = "6....1..1.5....3......6423.........6"
+const solution6x6 = "653421421653564312312564236145145236"
+
+const grid12x12 = ".....1.C8..2...C..9.A5..8.....3...4.5.7.1.....2..BC4.8.95..3..2..A.3.B.43..54.B....6..B..28...A.9..6.7...C....5A.4..29...4..2....3....687.5...1B"
+const solution12x12 = "A537B14C8692B14C8692A5378692A537B14C5A731BC468291BC468295A7368295A731BC437A54CB192864CB1928637A5928637A54CB1735AC41B2968C41B2968735A2968735AC41B"
+
+const grid16x16 = "..3EB1..86.2...9B.4.D...A.79.6.2....AF7.D.3E.1...F.9..G.B...D.3..DE3...46.2.FA..1....D.3..9..82G6..GF.9.......C4.A..6...1B.4.....E.54.B......9A.4C...E.....FG..6..867..F3.D...B...AF.28..C.13E........1.2G.8.7.....BE35..7F..G..2.6....AE..DC...9....G.8.4....5D"
+const solution16x16 = "D53EB14C86G2AF79B14CD53EAF7986G286G2AF79D53EB14CAF7986G2B14CD53E5DE31BC4682GFA971BC45DE3FA97682G682GFA975DE31BC4FA97682G1BC45DE33ED54CB1G28679AF4CB13ED579AFG286G28679AF3ED54CB179AFG2864CB13ED5E35DC41B2G6897FAC41BE35D97FA2G682G6897FAE35DC41B97FA2G68C41BE35D"
+
+// numbers write the symbols of a grid as numbers separated by spaces
+func numbers(g *solver.Geometry, grid string) string {
+	res := make([]string, 0, len(grid))
+	for _, c := range grid {
+		res = append(res, strconv.Itoa(strings.IndexRune(g.Symbols(), c)+1))
+	}
+	return strings.Join(res, " ")
+}
+
+func TestSudokuGeometry(t *testing.T) {
+	Convey("Given sudokus of different sizes and a solver", t, func() {
+		g4, _ := solver.NewGeometry(2, 2)
+		g6, _ := solver.NewGeometry(2, 3)
+		g12, _ := solver.NewGeometry(3, 4)
+		g16, _ := solver.NewGeometry(4, 4)
+
+		Convey("When NewGeometry is called with boxes too big or empty", func() {
+			_, errBig := solver.NewGeometry(5, 6)
+			_, errEmpty := solver.NewGeometry(0, 3)
+
+			Convey("Then return an error", func() {
+				So(errBig, ShouldNotBeNil)
+				So(errEmpty, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the geometry of a 6x6 sudoku is created", func() {
+			Convey("Then it has 36 squares and 6 symbols", func() {
+				So(g6.Size(), ShouldEqual, 6)
+				So(g6.Symbols(), ShouldEqual, "123456")
+				So(g6.Squares(), ShouldHaveLength, 36)
+				So(g6.Squares()[0], ShouldEqual, "A1")
+				So(g6.Squares()[35], ShouldEqual, "F6")
+			})
+		})
+
+		Convey("When SolveContext is called with sudokus of every size", func() {
+			cases := []struct {
+				g        *solver.Geometry
+				grid     string
+				solution string
+			}{
+				{g4, grid4x4, solution4x4},
+				{g6, grid6x6, solution6x6},
+				{g12, grid12x12, solution12x12},
+				{g16, grid16x16, solution16x16},
+				{solver.Classic, grid, ""},
+			}
+
+			Convey("Then show the solved sudokus", func() {
+				for _, c := range cases {
+					values, err := solver.SolveContext(context.Background(), c.grid, solver.WithGeometry(c.g))
+					So(err, ShouldBeNil)
+					So(values, ShouldHaveLength, c.g.Size()*c.g.Size())
+					if c.solution != "" {
+						So(c.g.Flatten(values), ShouldEqual, c.solution)
+					}
+				}
+			})
+		})
+
+		Convey("When Solve is called with a 6x6 sudoku without its geometry", func() {
+			_, err := solver.Solve(grid6x6)
+
+			Convey("Then return a GridSizeError", func() {
+				var sizeErr *solver.GridSizeError
+				So(errors.As(err, &sizeErr), ShouldBeTrue)
+				So(sizeErr.Expected, ShouldEqual, 81)
+				So(sizeErr.Size, ShouldEqual, 36)
+			})
+		})
+
+		Convey("When SolveContext is called with a 16x16 sudoku written with numbers", func() {
+			values, err := solver.SolveContext(context.Background(), numbers(g16, grid16x16), solver.WithGeometry(g16))
+
+			Convey("Then show the solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(g16.Flatten(values), ShouldEqual, solution16x16)
+			})
+		})
+
+		Convey("When SolveContext is called with a 16x16 sudoku written with lowercase letters", func() {
+			values, err := solver.SolveContext(context.Background(), strings.ToLower(grid16x16), solver.WithGeometry(g16))
+
+			Convey("Then show the solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(g16.Flatten(values), ShouldEqual, solution16x16)
+			})
+		})
+
+		Convey("When SolveContext is called with a 4x4 sudoku written with commas", func() {
+			values, err := solver.SolveContext(context.Background(), strings.Join(strings.Split(grid4x4, ""), ","), solver.WithGeometry(g4))
+
+			Convey("Then show the solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(g4.Flatten(values), ShouldEqual, solution4x4)
+			})
+		})
+
+		Convey("When Solve is called with a 6x6 sudoku containing a symbol of a bigger sudoku", func() {
+			_, err := solver.SolveContext(context.Background(), "7"+grid6x6[1:], solver.WithGeometry(g6))
+
+			Convey("Then return an InvalidCharacterError", func() {
+				var charErr *solver.InvalidCharacterError
+				So(errors.As(err, &charErr), ShouldBeTrue)
+				So(charErr.Index, ShouldEqual, 0)
+				So(charErr.Char, ShouldEqual, "7")
+			})
+		})
+
+		Convey("When CountSolutions is called with a 4x4 sudoku missing two symbols", func() {
+			_, err := solver.CountSolutions("1...............", 0, solver.WithGeometry(g4))
+
+			Convey("Then return a TooFewCluesError", func() {
+				var cluesErr *solver.TooFewCluesError
+				So(errors.As(err, &cluesErr), ShouldBeTrue)
+				So(cluesErr.MinDigits, ShouldEqual, 3)
+			})
+		})
+
+		Convey("When CountSolutions is called with an empty 4x4 sudoku and the lenient policy", func() {
+			n, err := solver.CountSolutions(strings.Repeat(".", 16), 0, solver.WithGeometry(g4), solver.WithValidation(solver.LenientValidation))
+
+			Convey("Then count the 288 4x4 sudokus", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 288)
+			})
+		})
+	})
+}
+
+const jigsawRegions = "111122333111222333112222333444555666444555666447555966477888996777888999777888999"
+const jigsawGrid = "4......9.6..4..3...7..5...........71..8..4..9..4..3..5..3.1.2.......5.1.........."
+const jigsawSolution = "435268197681497352972351468346529871528174639194683725753916284267845913819732546"
+
+func TestJigsawGeometry(t *testing.T) {
+	Convey("Given a jigsaw sudoku and a solver", t, func() {
+		g, err := solver.NewJigsawGeometry(jigsawRegions)
+		So(err, ShouldBeNil)
+
+		Convey("When SolveContext is called with the regions of the sudoku", func() {
+			values, err := solver.SolveContext(context.Background(), jigsawGrid, solver.WithGeometry(g))
+			unique, _ := solver.IsUnique(jigsawGrid, solver.WithGeometry(g))
+
+			Convey("Then show the solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(g.Flatten(values), ShouldEqual, jigsawSolution)
+				So(unique, ShouldBeTrue)
+			})
+		})
+
+		Convey("When Solve is called with a digit repeated in a region but not in a box", func() {
+			// A4 and C2 are both in the first region
+			grid := jigsawGrid[:3] + "8" + jigsawGrid[4:19] + "8" + jigsawGrid[20:]
+			_, err := solver.SolveContext(context.Background(), grid, solver.WithGeometry(g))
+
+			Convey("Then return a ConflictError with the conflicting cells", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+				So(conflictErr.Cells, ShouldResemble, []string{"A4", "C2"})
+			})
+		})
+
+		Convey("When NewJigsawGeometry is called with a map of the wrong size", func() {
+			_, err := solver.NewJigsawGeometry(jigsawRegions[1:])
+
+			Convey("Then return a RegionError", func() {
+				var regionErr *solver.RegionError
+				So(errors.As(err, &regionErr), ShouldBeTrue)
+				So(regionErr.Squares, ShouldEqual, 80)
+			})
+		})
+
+		Convey("When NewJigsawGeometry is called with a region of ten squares", func() {
+			_, err := solver.NewJigsawGeometry("1" + jigsawRegions[1:80] + "1")
+
+			Convey("Then return a RegionError with the size of the region", func() {
+				var regionErr *solver.RegionError
+				So(errors.As(err, &regionErr), ShouldBeTrue)
+				So(regionErr.Region, ShouldEqual, "1")
+				So(regionErr.Squares, ShouldEqual, 10)
+				So(regionErr.Expected, ShouldEqual, 9)
+			})
+		})
+
+		Convey("When NewJigsawGeometry is called with a region split in two", func() {
+			// The first two squares of the rows A and I are swapped
+			_, err := solver.NewJigsawGeometry("77" + jigsawRegions[2:72] + "11" + jigsawRegions[74:])
+
+			Convey("Then return a RegionError telling the region is disconnected", func() {
+				var regionErr *solver.RegionError
+				So(errors.As(err, &regionErr), ShouldBeTrue)
+				So(regionErr.Region, ShouldEqual, "7")
+				So(regionErr.Disconnected, ShouldBeTrue)
+				So(err.Error(), ShouldEqual, `Invalid region "7": its squares are not connected`)
+			})
+		})
+	})
+}
+
+const samuraiGrid = ".2...6..9.1......84...8.....4.6...2...9..71....8.2.........8.......5.8.7....9..12....9...6......436......4..5..8.....2.8...7......4.62......3.9...4.3..7....3........9..5.....4....94.6..7...7.......51...5.....5....1...........8....4..7..9.....9...............2....4..5.8......58..36............8...5..724...7.6....1.....42..826..3.97..7....6.....8....19....7.....4.7...3"
+const samuraiSolution = "123456789912345678457189263345678129689237145768129345214368957123456897365794812457893216798512436689217453532871694278531762984941623578143296584731876945321569874931562132485769456397182789612345123458967851423156789456279813924657289134789136245736918347256214365789132465897367891452549718362598724136786923415631942578261534978872513694375892641945687321894671523"
+
+const twodokuGrid = ".....6..............89..1...1......7.....94..79..485.35.............994..1....4..2.78..53...1..83.....3........82......3.14...2.......4.1....87.7.9...612"
+const twodokuSolution = "123456789459781236678923145214365897385179462796248513531892674235189942617358491267867534921678345142356798567829431893714526236187954415962873789543612"
+
+func TestMultiGridGeometry(t *testing.T) {
+	Convey("Given multi-grid sudokus and a solver", t, func() {
+		Convey("When the squares of a Samurai are listed", func() {
+			g := solver.Samurai
+
+			Convey("Then the five grids share their corner boxes and leave holes between them", func() {
+				So(g.Size(), ShouldEqual, 9)
+				So(len(g.Squares()), ShouldEqual, 5*81-4*9)
+				So(g.Squares()[:10], ShouldResemble, []string{"A1", "A2", "A3", "A4", "A5", "A6", "A7", "A8", "A9", "A13"})
+				So(g.SquareIndex("J1"), ShouldEqual, -1)
+				So(g.SquareIndex("J7"), ShouldBeGreaterThan, 0)
+				So(g.Squares()[len(g.Squares())-1], ShouldEqual, "U21")
+			})
+		})
+
+		Convey("When SolveContext is called with a Samurai and a Twodoku", func() {
+			samurai, errSamurai := solver.SolveContext(context.Background(), samuraiGrid, solver.WithGeometry(solver.Samurai))
+			twodoku, errTwodoku := solver.SolveContext(context.Background(), twodokuGrid, solver.WithGeometry(solver.Twodoku))
+			unique, _ := solver.IsUnique(samuraiGrid, solver.WithGeometry(solver.Samurai))
+
+			Convey("Then show the solved sudokus", func() {
+				So(errSamurai, ShouldBeNil)
+				So(solver.Samurai.Flatten(samurai), ShouldEqual, samuraiSolution)
+				So(unique, ShouldBeTrue)
+				So(errTwodoku, ShouldBeNil)
+				So(solver.Twodoku.Flatten(twodoku), ShouldEqual, twodokuSolution)
+			})
+		})
+
+		Convey("When Solve is called with a digit repeated on a row of the middle grid", func() {
+			// G7 is shared by the top left and the middle grids, G15 by the top right and the middle grids
+			grid := []byte(strings.Repeat(".", len(solver.Samurai.Squares())))
+			grid[solver.Samurai.SquareIndex("G7")] = '5'
+			grid[solver.Samurai.SquareIndex("G15")] = '5'
+			_, err := solver.SolveContext(context.Background(), string(grid), solver.WithGeometry(solver.Samurai),
+				solver.WithValidation(solver.LenientValidation))
+
+			Convey("Then return a ConflictError with the conflicting cells", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+				So(conflictErr.Cells, ShouldResemble, []string{"G7", "G15"})
+			})
+		})
+
+		Convey("When the diagonals of a Twodoku are listed", func() {
+			units := solver.Diagonal{}.Units(solver.Twodoku)
+
+			Convey("Then each grid has its own diagonals", func() {
+				So(len(units), ShouldEqual, 4)
+				So(units[2][0], ShouldEqual, solver.Twodoku.SquareIndex("G7"))
+				So(units[2][8], ShouldEqual, solver.Twodoku.SquareIndex("O15"))
+			})
+		})
+
+		Convey("When LayoutGeometry is called with the name of a layout", func() {
+			g, err := solver.LayoutGeometry("Samurai")
+			_, errUnknown := solver.LayoutGeometry("flower")
+
+			Convey("Then return its geometry or an UnknownLayoutError", func() {
+				So(err, ShouldBeNil)
+				So(g, ShouldEqual, solver.Samurai)
+				var layoutErr *solver.UnknownLayoutError
+				So(errors.As(errUnknown, &layoutErr), ShouldBeTrue)
+				So(layoutErr.Error(), ShouldEqual, `Unknown layout "flower": expected samurai, twodoku or butterfly`)
+			})
+		})
+
+		Convey("When NewMultiGridGeometry is called with grids which are not laid out on the boxes", func() {
+			_, errAligned := solver.NewMultiGridGeometry([][2]int{{0, 0}, {4, 6}})
+			_, errTwice := solver.NewMultiGridGeometry([][2]int{{0, 0}, {0, 0}})
+			_, errEmpty := solver.NewMultiGridGeometry(nil)
+
+			Convey("Then return an error", func() {
+				So(errAligned, ShouldNotBeNil)
+				So(errTwice, ShouldNotBeNil)
+				So(errEmpty, ShouldNotBeNil)
+			})
+		})
+	})
+}
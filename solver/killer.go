@@ -0,0 +1,328 @@
+package solver
+
+// maxCombinations bounds the number of combinations of digits kept for a group of squares,
+// the bigger groups are only pruned with the bounds of their sum
+const maxCombinations = 1024
+
+// Cage is a group of squares of a killer sudoku, named like the keys of the solved sudoku (A1, B5, D8,...),
+// whose values are distinct and add up to Sum
+type Cage struct {
+	Cells []string `json:"cells"`
+	Sum   int      `json:"sum"`
+}
+
+// Killer is the constraint of the killer sudoku: the values of each cage are distinct and add up to its sum.
+// The cages may not overlap but do not need to cover the grid.
+// Besides the combinations of digits of each cage, the propagation uses the 45 rule: the squares of a row,
+// column or box, or of consecutive rows or columns, which are not in the cages it contains add up to 45 times
+// the number of units minus the sum of these cages. The squares outside of a unit of the cages overlapping it
+// add up to the sum of these cages minus 45.
+type Killer struct {
+	NopConstraint
+	Cages []Cage `json:"cages"`
+}
+
+// sumGroup is a group of squares whose values add up to sum
+type sumGroup struct {
+	squares []int
+	sum     int
+	// distinct is set when the values of the squares are all different
+	distinct bool
+	// combinations holds the sets of distinct digits adding up to sum, nil if there are too many of them
+	// or if the values are not distinct
+	combinations []uint32
+}
+
+// killer is the Killer constraint compiled for a geometry
+type killer struct {
+	g      *Geometry
+	groups []sumGroup
+	// groupsOf holds the indexes of the groups of each square
+	groupsOf [][]int
+}
+
+// Compile check the cages and derive the groups of squares of the 45 rule.
+// Return a *CageError if a cage is not valid.
+func (k Killer) Compile(g *Geometry) (Constraint, error) {
+	kc := &killer{g: g, groupsOf: make([][]int, len(g.squares))}
+
+	cageOf := make([]int, len(g.squares))
+	for s := range cageOf {
+		cageOf[s] = -1
+	}
+
+	cages := make([][]int, len(k.Cages))
+	for i, c := range k.Cages {
+		for _, name := range c.Cells {
+			s := g.SquareIndex(name)
+			if s < 0 {
+				return nil, &CageError{Cage: i, Cell: name}
+			}
+			if cageOf[s] >= 0 {
+				return nil, &CageError{Cage: i, Cell: g.squares[s], Overlap: true}
+			}
+			cageOf[s] = i
+			cages[i] = append(cages[i], s)
+		}
+
+		// The values of a cage are distinct digits, their sum can be anything between the sum of the
+		// smallest ones and the sum of the biggest ones
+		n := len(cages[i])
+		if n == 0 || n > g.size || c.Sum < n*(n+1)/2 || c.Sum > n*(2*g.size-n+1)/2 {
+			return nil, &CageError{Cage: i, Sum: c.Sum, Cells: n}
+		}
+		kc.add(cages[i], c.Sum, true)
+	}
+
+	kc.addRule45(k.Cages, cages, cageOf)
+
+	return kc, nil
+}
+
+// Add a group of squares adding up to sum
+func (k *killer) add(squares []int, sum int, distinct bool) {
+	grp := sumGroup{squares: squares, sum: sum, distinct: distinct}
+	if distinct {
+		grp.combinations = combinations(k.g.size, len(squares), sum)
+	}
+
+	for _, s := range squares {
+		k.groupsOf[s] = append(k.groupsOf[s], len(k.groups))
+	}
+	k.groups = append(k.groups, grp)
+}
+
+// AddRule45 add the groups of squares found with the 45 rule, in every unit of the geometry and in the bands
+// made of consecutive rows or columns of each grid
+func (k *killer) addRule45(cs []Cage, cages [][]int, cageOf []int) {
+	size := k.g.size
+	total := size * (size + 1) / 2
+
+	areas := make([][][]int, 0, len(k.g.unitlist))
+	for _, unit := range k.g.unitlist {
+		areas = append(areas, [][]int{unit})
+	}
+
+	// The bands are made of the consecutive columns or rows of a grid
+	for _, o := range k.g.grids {
+		cols, rows := make([][]int, size), make([][]int, size)
+		for i := 0; i < size; i++ {
+			cols[i], rows[i] = make([]int, size), make([]int, size)
+			for j := 0; j < size; j++ {
+				cols[i][j] = k.g.at(o[0]+j, o[1]+i)
+				rows[i][j] = k.g.at(o[0]+i, o[1]+j)
+			}
+		}
+		for _, lines := range [][][]int{cols, rows} {
+			for n := 2; n < size; n++ {
+				for start := 0; start+n <= size; start++ {
+					areas = append(areas, lines[start:start+n])
+				}
+			}
+		}
+	}
+
+	for _, area := range areas {
+		var squares []int
+		for _, unit := range area {
+			squares = append(squares, unit...)
+		}
+
+		// The number of squares of each cage in the area, the cages being listed in order
+		inside, touched := map[int]int{}, []int(nil)
+		for _, s := range squares {
+			if c := cageOf[s]; c >= 0 {
+				if inside[c] == 0 {
+					touched = append(touched, c)
+				}
+				inside[c]++
+			}
+		}
+
+		// The innies: the squares of the area not in one of the cages it contains
+		innies, sum := []int(nil), len(area)*total
+		for _, c := range touched {
+			if inside[c] == len(cages[c]) {
+				sum -= cs[c].Sum
+			}
+		}
+		uncaged := false
+		for _, s := range squares {
+			c := cageOf[s]
+			uncaged = uncaged || c < 0
+			if c < 0 || inside[c] != len(cages[c]) {
+				innies = append(innies, s)
+			}
+		}
+		if len(innies) > 0 && len(innies) < len(squares) && len(innies) <= size {
+			// The squares of a single unit are distinct
+			k.add(innies, sum, len(area) == 1)
+		}
+
+		// The outies: the squares outside of a unit of the cages overlapping it, when the unit is covered by cages
+		if len(area) > 1 || uncaged {
+			continue
+		}
+		outies, sum := []int(nil), -total
+		for _, c := range touched {
+			sum += cs[c].Sum
+			if inside[c] < len(cages[c]) {
+				for _, s := range cages[c] {
+					if !contains(squares, s) {
+						outies = append(outies, s)
+					}
+				}
+			}
+		}
+		if len(outies) > 0 && len(outies) <= size {
+			k.add(outies, sum, false)
+		}
+	}
+}
+
+// Squares returns the squares of the groups
+func (k *killer) Squares(g *Geometry) []int {
+	var res []int
+	for s, groups := range k.groupsOf {
+		if len(groups) > 0 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Propagate prune the groups of square s, every group before the clues are placed
+func (k *killer) Propagate(b *Board, s int) bool {
+	if s < 0 {
+		for i := range k.groups {
+			if !k.prune(b, &k.groups[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, i := range k.groupsOf[s] {
+		if !k.prune(b, &k.groups[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Prune keeps the candidates of the squares of the group which can be part of a sum, and removes the digits
+// already placed from the other squares of a group of distinct values.
+// Return false if a contradiction is detected.
+func (k *killer) prune(b *Board, grp *sumGroup) bool {
+	if grp.combinations != nil {
+		// A combination is possible if each square can take one of its digits and each digit can be placed
+		var allowed uint32
+		for _, c := range grp.combinations {
+			var placed uint32
+			for _, s := range grp.squares {
+				v := b.Candidates(s) & c
+				if v == 0 {
+					placed = 0
+					break
+				}
+				placed |= v
+			}
+			if placed == c {
+				allowed |= c
+			}
+		}
+
+		for _, s := range grp.squares {
+			if !b.Keep(s, allowed) {
+				return false
+			}
+		}
+	} else if !k.bound(b, grp) {
+		return false
+	}
+
+	if grp.distinct {
+		for _, s := range grp.squares {
+			if v := b.Candidates(s); v&(v-1) == 0 {
+				for _, s2 := range grp.squares {
+					if s2 != s && !b.Eliminate(s2, v) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// Bound keeps the candidates of each square of the group between the sum minus the biggest values the other
+// squares can take and the sum minus their smallest values.
+// Return false if a contradiction is detected.
+func (k *killer) bound(b *Board, grp *sumGroup) bool {
+	low, high := 0, 0
+	for _, s := range grp.squares {
+		low += lowest(b.Candidates(s))
+		high += highest(b.Candidates(s))
+	}
+
+	for _, s := range grp.squares {
+		v := b.Candidates(s)
+		min := grp.sum - (high - highest(v))
+		max := grp.sum - (low - lowest(v))
+		if !b.Keep(s, between(min, max)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns the bitmask of the values from min to max
+func between(min, max int) uint32 {
+	if min < 1 {
+		min = 1
+	}
+	if max > maxSize {
+		max = maxSize
+	}
+	if max < min {
+		return 0
+	}
+	return (1<<uint(max) - 1) &^ (1<<uint(min-1) - 1)
+}
+
+// Combinations list the sets of n distinct digits, from 1 to size, adding up to sum.
+// Return nil if there are more than maxCombinations of them.
+func combinations(size, n, sum int) []uint32 {
+	res := []uint32{}
+
+	var combine func(from, n, sum int, digits uint32) bool
+	combine = func(from, n, sum int, digits uint32) bool {
+		if n == 0 {
+			if sum == 0 {
+				res = append(res, digits)
+			}
+			return len(res) <= maxCombinations
+		}
+		for d := from; d <= size && d <= sum; d++ {
+			if !combine(d+1, n-1, sum-d, digits|1<<uint(d-1)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !combine(1, n, sum, 0) {
+		return nil
+	}
+	return res
+}
+
+// Contains report whether s is one of the squares
+func contains(squares []int, s int) bool {
+	for _, s2 := range squares {
+		if s2 == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package solver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const killerCages = `[{"cells":["D8","D9"],"sum":15},{"cells":["G3","H3","G4","H4"],"sum":20},{"cells":["G9","H9","G8"],"sum":12},{"cells":["E2","F2","F1"],"sum":16},{"cells":["A6","A5","B5","A7"],"sum":28},{"cells":["A3","A2","A4"],"sum":11},{"cells":["F9","E9","E8","F8"],"sum":18},{"cells":["D1","E1"],"sum":15},{"cells":["B8","B7","C8"],"sum":14},{"cells":["C1","B1","A1","B2"],"sum":22},{"cells":["G5","G6","G7"],"sum":12},{"cells":["H2","G2","I2","H1"],"sum":26},{"cells":["D6","D5","E5"],"sum":18},{"cells":["F5","F4","F3"],"sum":16},{"cells":["D7","C7","C6"],"sum":8},{"cells":["D2","C2"],"sum":7},{"cells":["I3","I4","I5","I6"],"sum":24},{"cells":["B4","B3","C3"],"sum":11},{"cells":["C9","B9","A9","A8"],"sum":20},{"cells":["I9","I8"],"sum":12},{"cells":["E6","F6"],"sum":8},{"cells":["D4","D3"],"sum":9},{"cells":["E7","F7"],"sum":11},{"cells":["E4","E3"],"sum":6},{"cells":["I1"],"sum":1},{"cells":["H7","I7","H8","H6"],"sum":17},{"cells":["B6"],"sum":8},{"cells":["C5","C4"],"sum":9},{"cells":["H5"],"sum":9},{"cells":["G1"],"sum":2}]`
+const killerSolution = "417369825632158947958724316825437169791586432346912758289643571573291684164875293"
+
+// beforeGuess counts the values eliminated from each square before the first guess
+type beforeGuess struct {
+	solver.NopObserver
+	guessed    bool
+	eliminated map[string]int
+}
+
+func (o *beforeGuess) OnEliminate(square, digit string, reason solver.Reason) {
+	if !o.guessed {
+		o.eliminated[square]++
+	}
+}
+
+func (o *beforeGuess) OnGuess(square, digit string, depth int) {
+	o.guessed = true
+}
+
+func TestKillerSudoku(t *testing.T) {
+	Convey("Given the cages of a killer sudoku and a solver", t, func() {
+		var killer solver.Killer
+		So(json.Unmarshal([]byte(`{"cages":`+killerCages+`}`), &killer), ShouldBeNil)
+
+		blankGrid := strings.Repeat(".", 81)
+		lenient := solver.WithValidation(solver.LenientValidation)
+
+		Convey("When SolveContext is called with the cages and no clue", func() {
+			values, err := solver.SolveContext(context.Background(), blankGrid, lenient, solver.WithConstraints(killer))
+			unique, _ := solver.IsUnique(blankGrid, lenient, solver.WithConstraints(killer))
+
+			Convey("Then show the solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values), ShouldEqual, killerSolution)
+				So(unique, ShouldBeTrue)
+			})
+		})
+
+		Convey("When CountSolutions is called with a clue contradicting a cage", func() {
+			// I1 is the only square of a cage adding up to 1
+			n, err := solver.CountSolutions(blankGrid[:72]+"2"+blankGrid[73:], 0, lenient, solver.WithConstraints(killer))
+
+			Convey("Then no solution is found", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the 45 rule applies to a row covered by cages but one square", func() {
+			// The cages of the row A add up to 36, so A1 is 9
+			cages := []solver.Cage{
+				{Cells: []string{"A2", "A3"}, Sum: 3},
+				{Cells: []string{"A4", "A5", "A6"}, Sum: 15},
+				{Cells: []string{"A7", "A8", "A9"}, Sum: 18},
+			}
+			obs := &beforeGuess{eliminated: map[string]int{}}
+			values, err := solver.SolveContext(context.Background(), blankGrid, lenient,
+				solver.WithConstraints(solver.Killer{Cages: cages}), solver.WithObserver(obs))
+
+			Convey("Then the square left is known before any guess", func() {
+				So(err, ShouldBeNil)
+				So(values["A1"], ShouldEqual, "9")
+				So(obs.eliminated["A1"], ShouldEqual, 8)
+			})
+		})
+
+		Convey("When Solve is called with invalid cages", func() {
+			_, errUnknown := solver.SolveContext(context.Background(), blankGrid, lenient,
+				solver.WithConstraints(solver.Killer{Cages: []solver.Cage{{Cells: []string{"A1", "J1"}, Sum: 3}}}))
+			_, errOverlap := solver.SolveContext(context.Background(), blankGrid, lenient,
+				solver.WithConstraints(solver.Killer{Cages: []solver.Cage{{Cells: []string{"A1", "A2"}, Sum: 3}, {Cells: []string{"A2"}, Sum: 1}}}))
+			_, errSum := solver.SolveContext(context.Background(), blankGrid, lenient,
+				solver.WithConstraints(solver.Killer{Cages: []solver.Cage{{Cells: []string{"A1", "A2"}, Sum: 18}}}))
+
+			Convey("Then return a CageError describing the faulty cage", func() {
+				var cageErr *solver.CageError
+				So(errors.As(errUnknown, &cageErr), ShouldBeTrue)
+				So(cageErr.Cell, ShouldEqual, "J1")
+				So(errors.As(errOverlap, &cageErr), ShouldBeTrue)
+				So(cageErr.Cage, ShouldEqual, 1)
+				So(cageErr.Overlap, ShouldBeTrue)
+				So(errors.As(errSum, &cageErr), ShouldBeTrue)
+				So(errSum.Error(), ShouldEqual, "Invalid cage 0: 2 distinct digits can not add up to 18")
+			})
+		})
+	})
+}
@@ -0,0 +1,182 @@
+package solver
+
+import "math/bits"
+
+// Thermo is a thermometer: the values of its squares, named like A1 or B5, strictly increase from the bulb,
+// the first square, to the end
+type Thermo struct {
+	NopConstraint
+	Cells []string `json:"cells"`
+}
+
+// Arrow is an arrow: the value of its circle is the sum of the values of the squares along the arrow.
+// The values along the arrow may repeat, unless the squares are peers.
+type Arrow struct {
+	NopConstraint
+	Circle string   `json:"circle"`
+	Cells  []string `json:"cells"`
+}
+
+// Palindrome is a palindrome line: its squares read the same values from both ends
+type Palindrome struct {
+	NopConstraint
+	Cells []string `json:"cells"`
+}
+
+// Whisper is a German whisper line: adjacent squares along the line hold values at least 5 apart on a classic
+// sudoku, half the size of the sudoku rounded up otherwise
+type Whisper struct {
+	NopConstraint
+	Cells []string `json:"cells"`
+}
+
+// thermo is the Thermo constraint compiled for a geometry
+type thermo struct {
+	squares []int
+}
+
+// arrow is the Arrow constraint compiled for a geometry
+type arrow struct {
+	circle  int
+	squares []int
+}
+
+// Compile find the squares of the thermometer
+func (t Thermo) Compile(g *Geometry) (Constraint, error) {
+	squares, err := g.path("thermometer", t.Cells)
+	if err != nil {
+		return nil, err
+	}
+	return &thermo{squares: squares}, nil
+}
+
+// Squares returns the squares of the thermometer
+func (t *thermo) Squares(g *Geometry) []int {
+	return t.squares
+}
+
+// Propagate keeps the values of each square above the smallest value of the square before it and below the
+// biggest value of the square after it. Return false if a contradiction is detected.
+func (t *thermo) Propagate(b *Board, s int) bool {
+	for i := 1; i < len(t.squares); i++ {
+		if !b.Keep(t.squares[i], between(lowest(b.Candidates(t.squares[i-1]))+1, maxSize)) {
+			return false
+		}
+	}
+	for i := len(t.squares) - 2; i >= 0; i-- {
+		if !b.Keep(t.squares[i], between(1, highest(b.Candidates(t.squares[i+1]))-1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compile find the squares of the arrow
+func (a Arrow) Compile(g *Geometry) (Constraint, error) {
+	if len(a.Cells) == 0 {
+		return nil, &SquareError{Constraint: "arrow"}
+	}
+	squares, err := g.path("arrow", append([]string{a.Circle}, a.Cells...))
+	if err != nil {
+		return nil, err
+	}
+	return &arrow{circle: squares[0], squares: squares[1:]}, nil
+}
+
+// Squares returns the circle and the squares along the arrow
+func (a *arrow) Squares(g *Geometry) []int {
+	return append([]int{a.circle}, a.squares...)
+}
+
+// Propagate keeps the values of the circle between the smallest and the biggest sums of the arrow, and the
+// values along the arrow within what the circle allows. Return false if a contradiction is detected.
+func (a *arrow) Propagate(b *Board, s int) bool {
+	low, high := 0, 0
+	for _, sq := range a.squares {
+		low += lowest(b.Candidates(sq))
+		high += highest(b.Candidates(sq))
+	}
+
+	if !b.Keep(a.circle, between(low, high)) {
+		return false
+	}
+
+	circle := b.Candidates(a.circle)
+	for _, sq := range a.squares {
+		v := b.Candidates(sq)
+		min := lowest(circle) - (high - highest(v))
+		max := highest(circle) - (low - lowest(v))
+		if !b.Keep(sq, between(min, max)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compile the palindrome into pairs of squares holding the same value
+func (p Palindrome) Compile(g *Geometry) (Constraint, error) {
+	squares, err := g.path("palindrome", p.Cells)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]int
+	for i, j := 0, len(squares)-1; i < j; i, j = i+1, j-1 {
+		pairs = append(pairs, [2]int{squares[i], squares[j]})
+	}
+	return Pairwise{Pairs: pairs, Relation: func(a, b int) bool { return a == b }}.Compile(g)
+}
+
+// Compile the whisper into pairs of adjacent squares holding values far enough apart
+func (w Whisper) Compile(g *Geometry) (Constraint, error) {
+	squares, err := g.path("whisper", w.Cells)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]int
+	for i := 1; i < len(squares); i++ {
+		pairs = append(pairs, [2]int{squares[i-1], squares[i]})
+	}
+	gap := (g.size + 1) / 2
+	return Pairwise{Pairs: pairs, Relation: func(a, b int) bool { return a-b >= gap || b-a >= gap }}.Compile(g)
+}
+
+// Path find the index of each square of a line, or return a *SquareError naming the constraint if the line is
+// empty, or one of its squares does not exist, is repeated or does not touch the square before it
+func (g *Geometry) path(constraint string, cells []string) ([]int, error) {
+	if len(cells) == 0 {
+		return nil, &SquareError{Constraint: constraint}
+	}
+
+	res := make([]int, len(cells))
+	for i, name := range cells {
+		if res[i] = g.SquareIndex(name); res[i] < 0 {
+			return nil, &SquareError{Constraint: constraint, Square: name}
+		}
+		if contains(res[:i], res[i]) {
+			return nil, &SquareError{Constraint: constraint, Square: name, Repeated: true}
+		}
+		if i > 0 && !touching(g, res[i-1], res[i]) {
+			return nil, &SquareError{Constraint: constraint, Square: name, Previous: cells[i-1]}
+		}
+	}
+	return res, nil
+}
+
+// Touching report whether two different squares are next to each other, diagonally included
+func touching(g *Geometry, s1, s2 int) bool {
+	r1, c1 := g.position(s1)
+	r2, c2 := g.position(s2)
+	return s1 != s2 && r1-r2 <= 1 && r2-r1 <= 1 && c1-c2 <= 1 && c2-c1 <= 1
+}
+
+// Lowest returns the smallest of the values
+func lowest(values uint32) int {
+	return bits.TrailingZeros32(values) + 1
+}
+
+// Highest returns the biggest of the values
+func highest(values uint32) int {
+	return 32 - bits.LeadingZeros32(values)
+}
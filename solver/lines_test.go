@@ -0,0 +1,111 @@
+package solver_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLineConstraints(t *testing.T) {
+	Convey("Given an empty grid and a solver", t, func() {
+		blankGrid := strings.Repeat(".", 81)
+		lenient := solver.WithValidation(solver.LenientValidation)
+
+		solve := func(cs ...solver.Constraint) (map[string]string, error) {
+			return solver.SolveContext(context.Background(), blankGrid, lenient, solver.WithConstraints(cs...))
+		}
+		value := func(values map[string]string, square string) int {
+			return int(values[square][0] - '0')
+		}
+
+		Convey("When SolveContext is called with a thermometer along a row", func() {
+			values, err := solve(solver.Thermo{Cells: []string{"A9", "A8", "A7", "A6", "A5", "A4", "A3", "A2", "A1"}})
+
+			Convey("Then the values increase from the bulb", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values)[:9], ShouldEqual, "987654321")
+			})
+		})
+
+		Convey("When SolveContext is called with a thermometer longer than the number of digits", func() {
+			_, err := solve(solver.Thermo{Cells: []string{"A1", "B1", "C1", "D1", "E1", "F1", "G1", "H1", "I1", "I2"}})
+
+			Convey("Then return ErrNoSolution", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
+			})
+		})
+
+		Convey("When SolveContext is called with arrows", func() {
+			values, err := solve(
+				solver.Arrow{Circle: "A1", Cells: []string{"B2", "C3"}},
+				solver.Arrow{Circle: "E5", Cells: []string{"F6", "G7", "H8", "I9"}},
+			)
+
+			Convey("Then each circle holds the sum of its arrow", func() {
+				So(err, ShouldBeNil)
+				So(value(values, "A1"), ShouldEqual, value(values, "B2")+value(values, "C3"))
+				So(value(values, "E5"), ShouldEqual, value(values, "F6")+value(values, "G7")+value(values, "H8")+value(values, "I9"))
+			})
+		})
+
+		Convey("When SolveContext is called with a palindrome", func() {
+			values, err := solve(solver.Palindrome{Cells: []string{"A1", "B2", "C3", "D4", "E5"}})
+
+			Convey("Then the line reads the same from both ends", func() {
+				So(err, ShouldBeNil)
+				So(values["A1"], ShouldEqual, values["E5"])
+				So(values["B2"], ShouldEqual, values["D4"])
+			})
+		})
+
+		Convey("When SolveContext is called with a palindrome along a row", func() {
+			_, err := solve(solver.Palindrome{Cells: []string{"A1", "A2", "A3"}})
+
+			Convey("Then return ErrNoSolution", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
+			})
+		})
+
+		Convey("When SolveContext is called with a German whisper", func() {
+			cells := []string{"A1", "A2", "A3", "B3", "C3", "C4", "C5"}
+			values, err := solve(solver.Whisper{Cells: cells})
+
+			Convey("Then adjacent squares differ by at least 5", func() {
+				So(err, ShouldBeNil)
+				for i := 1; i < len(cells); i++ {
+					d := value(values, cells[i]) - value(values, cells[i-1])
+					So(d >= 5 || d <= -5, ShouldBeTrue)
+				}
+				So(values["A1"], ShouldNotEqual, "5")
+			})
+		})
+
+		Convey("When SolveContext is called with a line on an unknown square", func() {
+			_, err := solve(solver.Thermo{Cells: []string{"A1", "K1"}})
+
+			Convey("Then return a SquareError", func() {
+				var squareErr *solver.SquareError
+				So(errors.As(err, &squareErr), ShouldBeTrue)
+				So(err.Error(), ShouldEqual, `Invalid thermometer: unknown square "K1"`)
+			})
+		})
+
+		Convey("When SolveContext is called with an arrow without squares or lines on repeated or distant squares", func() {
+			_, errEmpty := solve(solver.Arrow{Circle: "A1"})
+			_, errRepeated := solve(solver.Thermo{Cells: []string{"A1", "A2", "A1"}})
+			_, errDistant := solve(solver.Arrow{Circle: "A1", Cells: []string{"A2", "A4"}})
+
+			Convey("Then return a SquareError instead of ErrNoSolution", func() {
+				var squareErr *solver.SquareError
+				So(errors.As(errEmpty, &squareErr), ShouldBeTrue)
+				So(errEmpty.Error(), ShouldEqual, "Invalid arrow: no square along the line")
+				So(errRepeated.Error(), ShouldEqual, "Invalid thermometer: square A1 is repeated")
+				So(errDistant.Error(), ShouldEqual, "Invalid arrow: squares A2 and A4 do not touch")
+			})
+		})
+	})
+}
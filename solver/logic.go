@@ -0,0 +1,786 @@
+package solver
+
+import (
+	"context"
+	"math/bits"
+	"strings"
+)
+
+// Technique names a deduction of the logical solver
+type Technique string
+
+const (
+	// FullHouse places the last digit missing from a unit
+	FullHouse Technique = "full house"
+	// NakedSingle places the only candidate of a square
+	NakedSingle Technique = "naked single"
+	// HiddenSingle places a digit in the only square of a unit which can hold it
+	HiddenSingle Technique = "hidden single"
+	// NakedPair removes the two candidates of two squares of a unit from the other squares of the unit
+	NakedPair Technique = "naked pair"
+	// HiddenPair removes the other candidates of the only two squares of a unit which can hold two digits
+	HiddenPair Technique = "hidden pair"
+	// NakedTriple removes the three candidates of three squares of a unit from the other squares of the unit
+	NakedTriple Technique = "naked triple"
+	// HiddenTriple removes the other candidates of the only three squares of a unit which can hold three digits
+	HiddenTriple Technique = "hidden triple"
+	// NakedQuad removes the four candidates of four squares of a unit from the other squares of the unit
+	NakedQuad Technique = "naked quad"
+	// HiddenQuad removes the other candidates of the only four squares of a unit which can hold four digits
+	HiddenQuad Technique = "hidden quad"
+	// Pointing removes a digit from a row or a column when it can only go in that line within a box
+	Pointing Technique = "pointing"
+	// BoxLineReduction removes a digit from a box when it can only go in that box within a row or a column
+	BoxLineReduction Technique = "box/line reduction"
+	// XWing removes a digit from two columns when it can only go in these columns within two rows, or the reverse
+	XWing Technique = "x-wing"
+	// Swordfish removes a digit from three columns when it can only go in these columns within three rows, or the reverse
+	Swordfish Technique = "swordfish"
+	// Jellyfish removes a digit from four columns when it can only go in these columns within four rows, or the reverse
+	Jellyfish Technique = "jellyfish"
+	// XYWing removes the digit shared by the two pincers of a pivot from the squares seeing both pincers
+	XYWing Technique = "xy-wing"
+	// XYZWing removes the digit shared by a pivot and its two pincers from the squares seeing all three
+	XYZWing Technique = "xyz-wing"
+	// WWing removes a digit from the squares seeing two pairs of the same digits linked by their other digit
+	WWing Technique = "w-wing"
+	// FinnedXWing is an x-wing whose rows have extra candidates, the fins, removing the digit next to the fins only
+	FinnedXWing Technique = "finned x-wing"
+	// SashimiXWing is a finned x-wing with a row left with a single candidate without the fins
+	SashimiXWing Technique = "sashimi x-wing"
+	// FinnedSwordfish is a swordfish whose rows have extra candidates, the fins, removing the digit next to the fins only
+	FinnedSwordfish Technique = "finned swordfish"
+	// SashimiSwordfish is a finned swordfish with a row left with a single candidate without the fins
+	SashimiSwordfish Technique = "sashimi swordfish"
+	// FinnedJellyfish is a jellyfish whose rows have extra candidates, the fins, removing the digit next to the fins only
+	FinnedJellyfish Technique = "finned jellyfish"
+	// SashimiJellyfish is a finned jellyfish with a row left with a single candidate without the fins
+	SashimiJellyfish Technique = "sashimi jellyfish"
+	// SimpleColoring removes a digit seeing both colors of the squares linked by the only two places of the digit
+	// in their units, or from a color seeing itself
+	SimpleColoring Technique = "simple coloring"
+	// MultiColoring removes a digit seeing the colors of two clusters of squares whose other colors see each other
+	MultiColoring Technique = "multi-coloring"
+	// XCycle places a digit in a square a chain of that digit loops through
+	XCycle Technique = "x-cycle"
+	// XChain removes a digit from the squares seeing both ends of a chain of that digit
+	XChain Technique = "x-chain"
+	// XYChain removes a digit from the squares seeing both ends of a chain of squares having two candidates
+	XYChain Technique = "xy-chain"
+	// UniqueRectangle1 removes the two digits of a rectangle from its only square holding other digits
+	UniqueRectangle1 Technique = "unique rectangle type 1"
+	// UniqueRectangle2 removes the extra digit of the two squares on a side of a rectangle from the squares seeing both
+	UniqueRectangle2 Technique = "unique rectangle type 2"
+	// UniqueRectangle3 removes the digits of a subset made with the extra digits of two squares of a rectangle
+	UniqueRectangle3 Technique = "unique rectangle type 3"
+	// UniqueRectangle4 removes a digit of a rectangle from two of its squares, the other one only going in them
+	UniqueRectangle4 Technique = "unique rectangle type 4"
+	// UniqueRectangle5 removes the extra digit of squares of a rectangle from the squares seeing all of them
+	UniqueRectangle5 Technique = "unique rectangle type 5"
+	// UniqueRectangle6 removes a digit of a rectangle from two opposite squares, it only goes in the rectangle in two lines
+	UniqueRectangle6 Technique = "unique rectangle type 6"
+	// AvoidableRectangle1 removes a digit from the last free square of a rectangle of squares placed by the solver
+	AvoidableRectangle1 Technique = "avoidable rectangle type 1"
+	// AvoidableRectangle2 removes the extra digit of the two free squares of a rectangle from the squares seeing both
+	AvoidableRectangle2 Technique = "avoidable rectangle type 2"
+	// BUGPlusOne places the digit of the only square with three candidates making the board a bivalue graveyard
+	BUGPlusOne Technique = "bug+1"
+	// SueDeCoq removes digits from a box and a line, the squares they share holding digits of both
+	SueDeCoq Technique = "sue de coq"
+	// ALSXZ removes a digit seeing two almost locked sets sharing a restricted common digit
+	ALSXZ Technique = "als-xz"
+	// ALSXYWing removes a digit seeing two almost locked sets, both sharing a restricted common digit with a third one
+	ALSXYWing Technique = "als-xy-wing"
+	// AIC concludes from the ends of an alternating inference chain of any digits, squares and groups of squares
+	AIC Technique = "alternating inference chain"
+	// CellForcingChain places or removes a digit whichever candidate of a square is placed
+	CellForcingChain Technique = "cell forcing chain"
+	// UnitForcingChain places or removes a digit wherever another digit is placed in a unit
+	UnitForcingChain Technique = "unit forcing chain"
+	// DigitForcingChain places or removes a digit whether a candidate is placed or eliminated
+	DigitForcingChain Technique = "digit forcing chain"
+	// Nishio removes a candidate whose placement leads to a contradiction
+	Nishio Technique = "nishio"
+)
+
+// Candidates are the digits of a square
+type Candidates struct {
+	Square string `json:"square"`
+	Digits string `json:"digits"`
+}
+
+// Step is a deduction of the logical solver: the technique applied in some units, the squares and the digits
+// it is based on, and the digits it places and eliminates. Placing a digit eliminates it from the peers.
+type Step struct {
+	Technique Technique `json:"technique"`
+	// Units are the units the deduction is made in, like "row A", "column 3" or "box D4-F6"
+	Units []string `json:"units,omitempty"`
+	// Cells are the squares the deduction is based on, holding Digits
+	Cells  []string `json:"cells"`
+	Digits string   `json:"digits"`
+	// Fins are the squares of Cells outside of a finned fish
+	Fins []string `json:"fins,omitempty"`
+	// Chain holds the links of the chain the deduction is based on, in order
+	Chain []Link `json:"chain,omitempty"`
+	// Branches holds the hypotheses of a forcing step, one of which is true, and what each one leads to
+	Branches []Branch `json:"branches,omitempty"`
+
+	Placed     []Candidates `json:"placed,omitempty"`
+	Eliminated []Candidates `json:"eliminated,omitempty"`
+}
+
+// String explain the step, like "hidden single in row A (5 at A3): places 5 in A3; removes 5 from B3 C1".
+// The steps based on a chain show it instead of their cells, like "x-chain (4)A1=(4)A5-(4)C5=(4)C7: removes 4 from A7".
+// The forcing steps add their branches, like "nishio (5 at A1) [5 in A1 => 3 in B2 => no candidate left in B9]: ..."
+func (st Step) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(st.Technique))
+	if len(st.Units) > 0 {
+		sb.WriteString(" in " + strings.Join(st.Units, " and "))
+	}
+	if len(st.Chain) > 0 {
+		sb.WriteString(" " + chainString(st.Chain))
+	} else {
+		sb.WriteString(" (" + st.Digits + " at " + strings.Join(st.Cells, ", "))
+		if len(st.Fins) > 0 {
+			sb.WriteString(" with fins at " + strings.Join(st.Fins, ", "))
+		}
+		sb.WriteString(")")
+	}
+	if len(st.Branches) > 0 {
+		var branches []string
+		for _, b := range st.Branches {
+			branches = append(branches, b.String())
+		}
+		sb.WriteString(" [" + strings.Join(branches, " | ") + "]")
+	}
+
+	sep := ": "
+	for _, c := range st.Placed {
+		sb.WriteString(sep + "places " + c.Digits + " in " + c.Square)
+		sep = ", "
+	}
+
+	// The eliminations are grouped by digits
+	var order []string
+	squares := map[string][]string{}
+	for _, c := range st.Eliminated {
+		if squares[c.Digits] == nil {
+			order = append(order, c.Digits)
+		}
+		squares[c.Digits] = append(squares[c.Digits], c.Square)
+	}
+	if len(order) > 0 {
+		if sep == ", " {
+			sep = "; "
+		}
+		sb.WriteString(sep + "removes ")
+		for i, d := range order {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(d + " from " + strings.Join(squares[d], " "))
+		}
+	}
+	return sb.String()
+}
+
+// techniques holds the deductions of the logical solver, from the simplest to the hardest.
+// Each one looks for a deduction on the board and applies it, returning nil if there is none.
+// A deduction naming its own technique, like the finned fish telling the sashimi ones, keeps it.
+var techniques = []struct {
+	technique Technique
+	find      func(l *logic) *Step
+}{
+	{FullHouse, (*logic).fullHouse},
+	{NakedSingle, (*logic).nakedSingle},
+	{HiddenSingle, (*logic).hiddenSingle},
+	{NakedPair, func(l *logic) *Step { return l.nakedSubset(2) }},
+	{HiddenPair, func(l *logic) *Step { return l.hiddenSubset(2) }},
+	{NakedTriple, func(l *logic) *Step { return l.nakedSubset(3) }},
+	{HiddenTriple, func(l *logic) *Step { return l.hiddenSubset(3) }},
+	{NakedQuad, func(l *logic) *Step { return l.nakedSubset(4) }},
+	{HiddenQuad, func(l *logic) *Step { return l.hiddenSubset(4) }},
+	{Pointing, func(l *logic) *Step { return l.locked(true) }},
+	{BoxLineReduction, func(l *logic) *Step { return l.locked(false) }},
+	{XWing, func(l *logic) *Step { return l.fish(2, false) }},
+	{Swordfish, func(l *logic) *Step { return l.fish(3, false) }},
+	{Jellyfish, func(l *logic) *Step { return l.fish(4, false) }},
+	{XYWing, (*logic).xyWing},
+	{XYZWing, (*logic).xyzWing},
+	{WWing, (*logic).wWing},
+	{UniqueRectangle1, func(l *logic) *Step { return l.uniqueRectangle(1) }},
+	{UniqueRectangle2, func(l *logic) *Step { return l.uniqueRectangle(2) }},
+	{UniqueRectangle3, func(l *logic) *Step { return l.uniqueRectangle(3) }},
+	{UniqueRectangle4, func(l *logic) *Step { return l.uniqueRectangle(4) }},
+	{UniqueRectangle5, func(l *logic) *Step { return l.uniqueRectangle(5) }},
+	{UniqueRectangle6, func(l *logic) *Step { return l.uniqueRectangle(6) }},
+	{AvoidableRectangle1, func(l *logic) *Step { return l.avoidableRectangle(1) }},
+	{AvoidableRectangle2, func(l *logic) *Step { return l.avoidableRectangle(2) }},
+	{BUGPlusOne, (*logic).bugPlusOne},
+	{FinnedXWing, func(l *logic) *Step { return l.fish(2, true) }},
+	{FinnedSwordfish, func(l *logic) *Step { return l.fish(3, true) }},
+	{FinnedJellyfish, func(l *logic) *Step { return l.fish(4, true) }},
+	{SueDeCoq, (*logic).sueDeCoq},
+	{SimpleColoring, (*logic).simpleColoring},
+	{MultiColoring, (*logic).multiColoring},
+	{XCycle, (*logic).xCycle},
+	{XChain, (*logic).xChain},
+	{XYChain, (*logic).xyChain},
+	{ALSXZ, (*logic).alsXZ},
+	{ALSXYWing, (*logic).alsXYWing},
+	{AIC, (*logic).aic},
+	// A digit forcing chain comes first: every fact it finds, a cell forcing chain on its square finds too
+	{DigitForcingChain, (*logic).digitForcingChain},
+	{CellForcingChain, (*logic).cellForcingChain},
+	{UnitForcingChain, (*logic).unitForcingChain},
+	{Nishio, (*logic).nishio},
+}
+
+// The kinds of units, to tell the lines from the boxes
+const (
+	unitOther = iota
+	unitRow
+	unitColumn
+	unitBox
+)
+
+// logic holds the candidates of the squares while the logical solver works on them
+type logic struct {
+	g      *Geometry
+	r      *rules
+	values board
+	// placed is set for the squares whose digit is known, given or placed by a step, given for the clues
+	placed []bool
+	given  []bool
+	// kinds and names hold the kind and the name of each unit of the rules
+	kinds []int
+	names []string
+	// step is the step being applied
+	step *Step
+	// broken is set when a digit is placed next to a peer holding it
+	broken bool
+	// uniqueness allows the techniques assuming a single solution
+	uniqueness bool
+	// rects holds the rectangles of squares whose digits can be swapped, once looked for
+	rects [][4]int
+	// deadly is set when the squares of a rectangle are all left with the same two digits
+	deadly bool
+	// forcing allows the techniques making hypotheses, propagated by p
+	forcing bool
+	p       *propagator
+	// done is closed once the solving is cancelled, the techniques then give up
+	done <-chan struct{}
+}
+
+// SolveLogical solve the sudoku in input like a human would, applying the techniques in order, from the full
+// house to the alternating inference chains, and never guessing. With WithForcingChains, the hypotheses of the
+// forcing chains are tried last, each one explained. It returns the candidates of the squares once solved or
+// once no technique applies, along with the steps taken.
+// The errors returned are ErrStuck when no technique applies, ErrNoSolution if a contradiction is found,
+// ctx.Err(), or the errors of an invalid grid or option. With WithUniqueness, ErrStuck is also returned when the
+// squares of a rectangle are all left with the same two digits: the sudoku has several solutions, which the
+// techniques assuming a single one can not go past. The techniques only use the units and the peers of
+// the constraints: the constraints pruning the candidates in other ways, like the cages of a killer sudoku,
+// are left out, the forcing chains excepted: their hypotheses are propagated with every constraint.
+func SolveLogical(ctx context.Context, grid string, opts ...Option) (map[string]string, []Step, error) {
+	o := newOptions(opts)
+
+	p, err := newPropagator(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	gr, err := clues(grid, o, p.r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l := newLogic(p.r)
+	l.uniqueness = o.uniqueness && l.uniqueness
+	l.forcing = o.forcing
+	l.done = ctx.Done()
+	for s, v := range gr {
+		if v > 0 {
+			l.place(s, 1<<uint(v-1))
+			l.given[s] = true
+		}
+	}
+	if !l.consistent() {
+		return nil, nil, ErrNoSolution
+	}
+
+	var steps []Step
+	for !l.solved() {
+		if err := ctx.Err(); err != nil {
+			return l.g.toMap(l.values), steps, err
+		}
+
+		st := l.next()
+		if err := ctx.Err(); err != nil {
+			return l.g.toMap(l.values), steps, err
+		}
+		if st == nil {
+			return l.g.toMap(l.values), steps, ErrStuck
+		}
+		steps = append(steps, *st)
+
+		if !l.consistent() {
+			return l.g.toMap(l.values), steps, ErrNoSolution
+		}
+	}
+	return l.g.toMap(l.values), steps, nil
+}
+
+// newLogic create the board of the logical solver, every digit being a candidate of every square
+func newLogic(r *rules) *logic {
+	l := &logic{g: r.g, r: r, values: make(board, len(r.g.squares)), placed: make([]bool, len(r.g.squares)),
+		given: make([]bool, len(r.g.squares)), uniqueness: true, p: &propagator{g: r.g, r: r}}
+	for s := range l.values {
+		l.values[s] = r.g.allDigits
+	}
+
+	for _, c := range r.constraints {
+		// The digits of a deadly pattern can only be swapped when the units are all the constraints
+		switch c.(type) {
+		case ClassicUnits, Diagonal, Windoku:
+		default:
+			l.uniqueness = false
+		}
+
+		up, ok := c.(UnitProvider)
+		if !ok {
+			continue
+		}
+		for _, unit := range up.Units(r.g) {
+			kind, name := l.unitKind(c, unit)
+			l.kinds = append(l.kinds, kind)
+			l.names = append(l.names, name)
+		}
+	}
+	return l
+}
+
+// UnitKind find the kind and the name of a unit of constraint c: "row A" and "column 3" for the lines of a single
+// grid, otherwise the kind of the unit followed by its first and last squares, like "box D4-F6"
+func (l *logic) unitKind(c Constraint, unit []int) (int, string) {
+	first, last := unit[0], unit[0]
+	sameRow, sameCol := true, true
+	for _, s := range unit {
+		if s < first {
+			first = s
+		}
+		if s > last {
+			last = s
+		}
+		r, col := l.g.position(s)
+		r0, col0 := l.g.position(unit[0])
+		sameRow, sameCol = sameRow && r == r0, sameCol && col == col0
+	}
+	span := l.g.squares[first] + "-" + l.g.squares[last]
+
+	switch c.(type) {
+	case ClassicUnits:
+		single := len(l.g.grids) == 1
+		switch {
+		case sameRow && single:
+			return unitRow, "row " + l.g.squares[first][:1]
+		case sameRow:
+			return unitRow, "row " + span
+		case sameCol && single:
+			return unitColumn, "column " + l.g.squares[first][1:]
+		case sameCol:
+			return unitColumn, "column " + span
+		case l.g.irregular:
+			return unitBox, "region " + span
+		}
+		return unitBox, "box " + span
+	case Diagonal:
+		return unitOther, "diagonal " + span
+	case Windoku:
+		return unitOther, "window " + span
+	}
+	return unitOther, "unit " + span
+}
+
+// Next apply the first technique finding a deduction, return nil if none does, if the solving is cancelled or
+// if a deadly pattern is found
+func (l *logic) next() *Step {
+	for _, t := range techniques {
+		if l.stopped() || l.deadly {
+			return nil
+		}
+		if uniquenessTechniques[t.technique] && !l.uniqueness || forcingTechniques[t.technique] && !l.forcing {
+			continue
+		}
+		if st := t.find(l); st != nil {
+			if st.Technique == "" {
+				st.Technique = t.technique
+			}
+			return st
+		}
+	}
+	return nil
+}
+
+// Stopped report whether the solving was cancelled
+func (l *logic) stopped() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Solved report whether the digit of every square is known
+func (l *logic) solved() bool {
+	for _, p := range l.placed {
+		if !p {
+			return false
+		}
+	}
+	return true
+}
+
+// Consistent report whether every square has a candidate and every unit can still hold each digit once
+func (l *logic) consistent() bool {
+	if l.broken {
+		return false
+	}
+	for _, v := range l.values {
+		if v == 0 {
+			return false
+		}
+	}
+	for _, unit := range l.r.unitlist {
+		var all, placed uint32
+		for _, s := range unit {
+			all |= l.values[s]
+			if l.placed[s] {
+				if placed&l.values[s] != 0 {
+					return false
+				}
+				placed |= l.values[s]
+			}
+		}
+		if all != l.g.allDigits {
+			return false
+		}
+	}
+	return true
+}
+
+// Begin start recording a step based on the digits of squares in units
+func (l *logic) begin(units []int, squares []int, digits uint32) *Step {
+	st := &Step{Digits: digitString(digits)}
+	for _, u := range units {
+		st.Units = append(st.Units, l.names[u])
+	}
+	for _, s := range squares {
+		st.Cells = append(st.Cells, l.g.squares[s])
+	}
+	l.step = st
+	return st
+}
+
+// Place the digit d (as a bit) in square s and eliminate it from the peers, recording it in the current step
+func (l *logic) place(s int, d uint32) {
+	l.values[s] = d
+	l.placed[s] = true
+	if l.step != nil {
+		l.step.Placed = append(l.step.Placed, Candidates{Square: l.g.squares[s], Digits: digitString(d)})
+	}
+
+	for _, s2 := range l.r.peers[s] {
+		if !l.placed[s2] {
+			l.eliminate(s2, d)
+		} else if l.values[s2] == d {
+			l.broken = true
+		}
+	}
+}
+
+// Eliminate the digits from square s, recording them in the current step.
+// Return whether one of them was a candidate.
+func (l *logic) eliminate(s int, digits uint32) bool {
+	digits &= l.values[s]
+	if digits == 0 {
+		return false
+	}
+	l.values[s] &^= digits
+
+	if l.step != nil {
+		name := l.g.squares[s]
+		for i, c := range l.step.Eliminated {
+			if c.Square == name {
+				l.step.Eliminated[i].Digits = digitString(digitMask(c.Digits) | digits)
+				return true
+			}
+		}
+		l.step.Eliminated = append(l.step.Eliminated, Candidates{Square: name, Digits: digitString(digits)})
+	}
+	return true
+}
+
+// FullHouse places the digit missing from a unit having a single empty square
+func (l *logic) fullHouse() *Step {
+	for u, unit := range l.r.unitlist {
+		free, n, missing := -1, 0, l.g.allDigits
+		for _, s := range unit {
+			if l.placed[s] {
+				missing &^= l.values[s]
+			} else {
+				free, n = s, n+1
+			}
+		}
+
+		if n == 1 && bits.OnesCount32(missing) == 1 && l.values[free]&missing != 0 {
+			st := l.begin([]int{u}, []int{free}, missing)
+			l.place(free, missing)
+			return st
+		}
+	}
+	return nil
+}
+
+// NakedSingle places the only candidate of a square
+func (l *logic) nakedSingle() *Step {
+	for s, v := range l.values {
+		if !l.placed[s] && bits.OnesCount32(v) == 1 {
+			st := l.begin(nil, []int{s}, v)
+			l.place(s, v)
+			return st
+		}
+	}
+	return nil
+}
+
+// HiddenSingle places a digit in the only square of a unit which can hold it
+func (l *logic) hiddenSingle() *Step {
+	for u, unit := range l.r.unitlist {
+		for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+			n, place := 0, -1
+			for _, s := range unit {
+				if l.values[s]&d != 0 {
+					n, place = n+1, s
+				}
+			}
+
+			if n == 1 && !l.placed[place] {
+				st := l.begin([]int{u}, []int{place}, d)
+				l.place(place, d)
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// NakedSubset finds n squares of a unit whose candidates are n digits, and eliminates these digits from the
+// other squares of the unit
+func (l *logic) nakedSubset(n int) *Step {
+	for u, unit := range l.r.unitlist {
+		var free, small []int
+		for _, s := range unit {
+			if !l.placed[s] {
+				free = append(free, s)
+				if c := bits.OnesCount32(l.values[s]); c >= 2 && c <= n {
+					small = append(small, s)
+				}
+			}
+		}
+		if len(free) <= n {
+			continue
+		}
+
+		var st *Step
+		subsets(len(small), n, func(picked []int) bool {
+			squares, digits := make([]int, n), uint32(0)
+			for i, p := range picked {
+				squares[i] = small[p]
+				digits |= l.values[small[p]]
+			}
+			if bits.OnesCount32(digits) != n || !l.removable(free, squares, digits) {
+				return false
+			}
+
+			st = l.begin([]int{u}, squares, digits)
+			for _, s := range free {
+				if !contains(squares, s) {
+					l.eliminate(s, digits)
+				}
+			}
+			return true
+		})
+		if st != nil {
+			return st
+		}
+	}
+	return nil
+}
+
+// HiddenSubset finds n digits which can only go in n squares of a unit, and eliminates the other candidates
+// of these squares
+func (l *logic) hiddenSubset(n int) *Step {
+	for u, unit := range l.r.unitlist {
+		// The places of each digit left in the unit, as a bitmask of the indexes of the squares in the unit
+		var digits []uint32
+		var places []uint32
+		for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+			var pl uint32
+			placed := false
+			for i, s := range unit {
+				if l.values[s]&d != 0 {
+					pl |= 1 << uint(i)
+					placed = placed || l.placed[s]
+				}
+			}
+			if c := bits.OnesCount32(pl); !placed && c >= 2 && c <= n {
+				digits = append(digits, d)
+				places = append(places, pl)
+			}
+		}
+
+		var st *Step
+		subsets(len(digits), n, func(picked []int) bool {
+			var pl, ds uint32
+			for _, p := range picked {
+				pl |= places[p]
+				ds |= digits[p]
+			}
+			if bits.OnesCount32(pl) != n {
+				return false
+			}
+
+			var squares []int
+			for i, s := range unit {
+				if pl&(1<<uint(i)) != 0 {
+					squares = append(squares, s)
+				}
+			}
+			others := false
+			for _, s := range squares {
+				others = others || l.values[s]&^ds != 0
+			}
+			if !others {
+				return false
+			}
+
+			st = l.begin([]int{u}, squares, ds)
+			for _, s := range squares {
+				l.eliminate(s, l.g.allDigits&^ds)
+			}
+			return true
+		})
+		if st != nil {
+			return st
+		}
+	}
+	return nil
+}
+
+// Locked finds a digit which can only go in the intersection of a box and a line, and eliminates it from the rest
+// of the other unit: a box pointing to a row or a column when pointing is set, otherwise a line claiming the digit
+// from a box, the box/line reduction.
+func (l *logic) locked(pointing bool) *Step {
+	for u, unit := range l.r.unitlist {
+		if isLine := l.kinds[u] == unitRow || l.kinds[u] == unitColumn; l.kinds[u] != unitBox && !isLine || isLine == pointing {
+			continue
+		}
+
+		for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+			var squares []int
+			placed := false
+			for _, s := range unit {
+				if l.values[s]&d != 0 {
+					squares = append(squares, s)
+					placed = placed || l.placed[s]
+				}
+			}
+			if placed || len(squares) < 2 {
+				continue
+			}
+
+			// The units of the other kind holding all the squares
+			for _, v := range l.r.units[squares[0]] {
+				if isBox := l.kinds[v] == unitBox; l.kinds[v] == unitOther || isBox == pointing || v == u || !l.within(squares, v) {
+					continue
+				}
+
+				var rest []int
+				for _, s := range l.r.unitlist[v] {
+					if !contains(unit, s) && l.values[s]&d != 0 {
+						rest = append(rest, s)
+					}
+				}
+				if len(rest) == 0 {
+					continue
+				}
+
+				st := l.begin([]int{u, v}, squares, d)
+				for _, s := range rest {
+					l.eliminate(s, d)
+				}
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// Within report whether all the squares are in unit u
+func (l *logic) within(squares []int, u int) bool {
+	for _, s := range squares {
+		if !contains(l.r.units[s], u) {
+			return false
+		}
+	}
+	return true
+}
+
+// Removable report whether one of the free squares which are not in squares has one of the digits
+func (l *logic) removable(free, squares []int, digits uint32) bool {
+	for _, s := range free {
+		if !contains(squares, s) && l.values[s]&digits != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Subsets call f with every set of n indexes below size, in increasing order, until f returns true
+func subsets(size, n int, f func(picked []int) bool) bool {
+	picked := make([]int, n)
+	var pick func(from, i int) bool
+	pick = func(from, i int) bool {
+		if i == n {
+			return f(picked)
+		}
+		for p := from; p <= size-(n-i); p++ {
+			picked[i] = p
+			if pick(p+1, i+1) {
+				return true
+			}
+		}
+		return false
+	}
+	return pick(0, 0)
+}
+
+// DigitString convert a bitmask of values to their symbols, in order
+func digitString(digits uint32) string {
+	var sb strings.Builder
+	for ; digits != 0; digits &= digits - 1 {
+		sb.WriteByte(symbols[bits.TrailingZeros32(digits)])
+	}
+	return sb.String()
+}
+
+// DigitMask convert symbols to their bitmask
+func digitMask(digits string) uint32 {
+	var res uint32
+	for _, c := range digits {
+		res |= 1 << uint(strings.IndexRune(symbols, c))
+	}
+	return res
+}
@@ -0,0 +1,444 @@
+package solver_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// logicGrid needs subsets and pointing candidates on top of the singles
+const logicGrid = ".1...67..4...9..2.....2...6..7...395..587..1............8....419....4.....2..5.7."
+const logicSolution = "213546789456798123789123456827461395635879214194352867568237941971684532342915678"
+
+// chainGrid needs an x-chain of 9 removing it from E9
+const chainGrid = "....2.5.6.6..........1.7..42..5......7.8..4....6.41.7..5....1.289....7....49....."
+const chainSolution = "137429586469358217528167934241573869375896421986241375653784192892615743714932658"
+
+// cycleGrid needs an x-cycle placing 5 in A1
+const cycleGrid = ".....9.1.97...246..2..4.7....5.7.9....7.28..4.....1....4.1.632..5....6..........8"
+
+// rectangleGrid has a unique rectangle of type 1
+const rectangleGrid = "..5...987.4..5...1..7......2...48....9.1.....6..2.....3..6..2.......9.7.......5.."
+
+// uniquenessSteps hold sudokus whose solving with WithUniqueness takes a step of a technique relying on a single
+// solution, the first step of the technique being the one given
+var uniquenessSteps = []struct {
+	grid string
+	step string
+}{
+	{".8.6.........746......2.8.5....4...61.4......79..8.....4.15..7.2....39........5.2",
+		"unique rectangle type 2 (17 at C2, C3, I2, I3): removes 6 from C1"},
+	{"78............5.....123.4........1..9.38...4...649..2..6...8..73.7.5....1....43..",
+		"unique rectangle type 5 (19 at A4, A6, H4, H6): removes 6 from B4"},
+	{"8......93..4...15....3..24..8.9.4...6....1.32.....5............3.17..5.9..74.....",
+		"avoidable rectangle type 1 (26 at B4, B5, F4, F5): removes 2 from B5"},
+	{"...2..5766....12....7...4..9.5..8..4....1.....1.4.9.3...2.75......1.....1.4...8..",
+		"avoidable rectangle type 2 (38 at A1, A2, G1, G2): removes 9 from H2 I2"},
+	{".5..2...1...6.53.........8.2..76.9..8...3.4..76..1......2...83...6.4..5......1..6",
+		"bug+1 (389 at A3): places 3 in A3; removes 3 from C3 I3 A1 A6"},
+}
+
+// deadlyGrid has two solutions, 1 and 3 being swappable between D6, D9, E6 and E9
+const deadlyGrid = "53467891267219534819834256785976.42.42685.79.713924856961537284287419635345286179"
+
+// forcingGrid needs unit and digit forcing chains
+const forcingGrid = ".......4...2..4..1.7..5..9...3..7....4..6....6..1..8...2....1..85.9...6.....8...3"
+
+// cellForcingGrid needs cell forcing chains
+const cellForcingGrid = "9.4..5...25.6..1..31......8.7...9...4..26......147....7.......2...3..8.6.4.....9."
+
+// inkalaGrid is known to need far more than subsets and locked candidates
+const inkalaGrid = "8..........36......7..9.2...5...7.......457.....1...3...1....68..85...1..9....4.."
+
+// cancelledDuringStep is done from the start but reports it only after the first check of Err, as when the
+// context is cancelled while a technique runs
+type cancelledDuringStep struct {
+	context.Context
+	checks int
+}
+
+func (c *cancelledDuringStep) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+func (c *cancelledDuringStep) Err() error {
+	if c.checks++; c.checks > 1 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestLogicalSolver(t *testing.T) {
+	Convey("Given sudokus and the logical solver", t, func() {
+		lenient := solver.WithValidation(solver.LenientValidation)
+
+		Convey("When SolveLogical is called with a sudoku needing pairs and pointing candidates", func() {
+			values, steps, err := solver.SolveLogical(context.Background(), logicGrid, lenient)
+
+			Convey("Then show the solved sudoku and the steps explaining each digit", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values), ShouldEqual, logicSolution)
+
+				used := map[solver.Technique]bool{}
+				placed := 0
+				for _, st := range steps {
+					used[st.Technique] = true
+					for _, p := range st.Placed {
+						So(p.Digits, ShouldEqual, logicSolution[solver.Classic.SquareIndex(p.Square):][:1])
+						placed++
+					}
+					for _, e := range st.Eliminated {
+						So(e.Digits, ShouldNotContainSubstring, logicSolution[solver.Classic.SquareIndex(e.Square):][:1])
+					}
+				}
+				So(placed, ShouldEqual, strings.Count(logicGrid, "."))
+				So(used[solver.NakedPair], ShouldBeTrue)
+				So(used[solver.HiddenPair], ShouldBeTrue)
+				So(used[solver.Pointing], ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveLogical is called with an easy sudoku", func() {
+			_, steps, err := solver.SolveLogical(context.Background(), easyGrid)
+
+			Convey("Then the singles are enough", func() {
+				So(err, ShouldBeNil)
+				for _, st := range steps {
+					So(st.Technique, ShouldBeIn, []solver.Technique{solver.FullHouse, solver.NakedSingle, solver.HiddenSingle})
+				}
+			})
+		})
+
+		Convey("When SolveLogical is called with the top95 grids", func() {
+			used := map[solver.Technique]bool{}
+			solved := 0
+			sound := true
+			shaped := true
+			for _, grid := range fromFile("./_tests/top95.txt") {
+				values, _ := solver.Solve(grid)
+				solution := solver.Classic.Flatten(values)
+
+				_, steps, err := solver.SolveLogical(context.Background(), grid, solver.WithUniqueness())
+				if err == nil {
+					solved++
+				}
+				for _, st := range steps {
+					used[st.Technique] = true
+					for _, e := range st.Eliminated {
+						sound = sound && !strings.Contains(e.Digits, solution[solver.Classic.SquareIndex(e.Square):][:1])
+					}
+					for _, p := range st.Placed {
+						sound = sound && p.Digits == solution[solver.Classic.SquareIndex(p.Square):][:1]
+					}
+
+					switch st.Technique {
+					case solver.XWing, solver.FinnedXWing, solver.SashimiXWing:
+						shaped = shaped && len(st.Units) == 4
+					case solver.Swordfish, solver.FinnedSwordfish, solver.SashimiSwordfish:
+						shaped = shaped && len(st.Units) == 6
+					case solver.XYWing, solver.XYZWing:
+						shaped = shaped && len(st.Cells) == 3
+					case solver.WWing:
+						shaped = shaped && len(st.Cells) == 4 && len(st.Units) == 1
+					}
+					for i, lk := range st.Chain {
+						// The chains alternate strong and weak links, starting with a strong one, coloring using
+						// strong links only
+						shaped = shaped && (lk.Strong || i%2 == 1) && (i == 0 || lk.From.String() == st.Chain[i-1].To.String())
+					}
+					if strings.HasPrefix(string(st.Technique), "finned") || strings.HasPrefix(string(st.Technique), "sashimi") {
+						shaped = shaped && len(st.Fins) > 0
+					}
+				}
+			}
+
+			Convey("Then the fish, the wings, the rectangles, the sets and the chains solve most of them without any wrong step", func() {
+				So(sound, ShouldBeTrue)
+				So(shaped, ShouldBeTrue)
+				So(solved, ShouldBeGreaterThanOrEqualTo, 68)
+				for _, t := range []solver.Technique{solver.XWing, solver.Swordfish, solver.XYWing, solver.XYZWing, solver.WWing,
+					solver.FinnedXWing, solver.SashimiXWing, solver.FinnedSwordfish, solver.SimpleColoring, solver.MultiColoring,
+					solver.XYChain, solver.AIC, solver.UniqueRectangle1, solver.UniqueRectangle2, solver.UniqueRectangle3,
+					solver.UniqueRectangle4, solver.UniqueRectangle6, solver.BUGPlusOne, solver.SueDeCoq, solver.ALSXZ,
+					solver.ALSXYWing} {
+					So(used[t], ShouldBeTrue)
+				}
+			})
+		})
+
+		Convey("When SolveLogical is called without WithUniqueness on a sudoku having a unique rectangle", func() {
+			_, steps, err := solver.SolveLogical(context.Background(), rectangleGrid)
+
+			Convey("Then the techniques assuming a single solution are left out", func() {
+				So(err, ShouldBeNil)
+				for _, st := range steps {
+					So(string(st.Technique), ShouldNotStartWith, "unique rectangle")
+					So(string(st.Technique), ShouldNotStartWith, "avoidable rectangle")
+					So(st.Technique, ShouldNotEqual, solver.BUGPlusOne)
+				}
+			})
+		})
+
+		Convey("When SolveLogical is called with WithUniqueness on a sudoku having a unique rectangle", func() {
+			_, steps, err := solver.SolveLogical(context.Background(), rectangleGrid, solver.WithUniqueness())
+
+			Convey("Then the unique rectangle is used", func() {
+				So(err, ShouldBeNil)
+				used := false
+				for _, st := range steps {
+					used = used || st.Technique == solver.UniqueRectangle1
+				}
+				So(used, ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveLogical is called with WithUniqueness on sudokus needing each technique assuming a single solution", func() {
+			for _, c := range uniquenessSteps {
+				_, steps, _ := solver.SolveLogical(context.Background(), c.grid, solver.WithUniqueness())
+				values, _ := solver.Solve(c.grid)
+				solution := solver.Classic.Flatten(values)
+
+				technique := solver.Technique(c.step[:strings.Index(c.step, " (")])
+				Convey("Then take a step of "+string(technique)+" on "+c.grid+" without any wrong step", func() {
+					var found *solver.Step
+					for i, st := range steps {
+						for _, e := range st.Eliminated {
+							So(e.Digits, ShouldNotContainSubstring, solution[solver.Classic.SquareIndex(e.Square):][:1])
+						}
+						if found == nil && st.Technique == technique {
+							found = &steps[i]
+						}
+					}
+					So(found, ShouldNotBeNil)
+					So(found.String(), ShouldEqual, c.step)
+				})
+			}
+		})
+
+		Convey("When SolveLogical is called with WithUniqueness on a sudoku having several solutions", func() {
+			values, steps, err := solver.SolveLogical(context.Background(), deadlyGrid, solver.WithUniqueness())
+
+			Convey("Then stop at the rectangle whose squares all hold the same two digits", func() {
+				So(err, ShouldEqual, solver.ErrStuck)
+				So(steps, ShouldBeEmpty)
+				So(values["D6"], ShouldEqual, "13")
+				So(values["E9"], ShouldEqual, "13")
+			})
+		})
+
+		Convey("When SolveLogical is called with a sudoku needing an x-chain", func() {
+			values, steps, err := solver.SolveLogical(context.Background(), chainGrid, lenient)
+
+			Convey("Then explain the elimination with the links of the chain", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values), ShouldEqual, chainSolution)
+
+				var chain *solver.Step
+				for i := range steps {
+					if steps[i].Technique == solver.XChain {
+						chain = &steps[i]
+						break
+					}
+				}
+				So(chain, ShouldNotBeNil)
+				So(chain.String(), ShouldEqual, "x-chain (9)E5=(9)B5-(9)A6=(9)A1-(9)F1=(9)F9: removes 9 from E9")
+				So(chain.Cells, ShouldResemble, []string{"E5", "B5", "A6", "A1", "F1", "F9"})
+			})
+		})
+
+		Convey("When SolveLogical is called with a sudoku needing an x-cycle", func() {
+			values, steps, err := solver.SolveLogical(context.Background(), cycleGrid)
+			solution, _ := solver.SolveContext(context.Background(), cycleGrid)
+
+			Convey("Then explain the placement with the links of the loop", func() {
+				So(err, ShouldBeNil)
+				So(values, ShouldResemble, solution)
+
+				var cycle *solver.Step
+				for i := range steps {
+					if steps[i].Technique == solver.XCycle {
+						cycle = &steps[i]
+						break
+					}
+				}
+				So(cycle, ShouldNotBeNil)
+				So(cycle.String(), ShouldEqual, "x-cycle (5)A1=(5)C1-(5)C6=(5)I6-(5)G5=(5)G9-(5)A9=(5)A1: places 5 in A1; removes 5 from C1 A9")
+				So(cycle.Chain, ShouldHaveLength, 7)
+				So(cycle.Chain[0].From.Squares, ShouldResemble, []string{"A1"})
+				So(cycle.Chain[6].To.Squares, ShouldResemble, []string{"A1"})
+				for i, lk := range cycle.Chain {
+					So(lk.Strong, ShouldEqual, i%2 == 0)
+				}
+				So(cycle.Placed, ShouldResemble, []solver.Candidates{{Square: "A1", Digits: "5"}})
+				So(cycle.Eliminated, ShouldResemble, []solver.Candidates{{Square: "C1", Digits: "5"}, {Square: "A9", Digits: "5"}})
+			})
+		})
+
+		Convey("When SolveLogical is called with a sudoku too hard for the techniques", func() {
+			values, _, err := solver.SolveLogical(context.Background(), inkalaGrid, lenient)
+
+			Convey("Then return ErrStuck with the candidates left", func() {
+				So(err, ShouldEqual, solver.ErrStuck)
+				So(len(values), ShouldEqual, 81)
+				So(len(values["A2"]), ShouldBeGreaterThan, 1)
+			})
+		})
+
+		Convey("When the context of SolveLogical is cancelled while a technique runs", func() {
+			ctx := &cancelledDuringStep{Context: context.Background()}
+			values, steps, err := solver.SolveLogical(ctx, forcingGrid, solver.WithForcingChains())
+
+			Convey("Then stop within the step and return the error of the context", func() {
+				So(err, ShouldEqual, context.Canceled)
+				So(steps, ShouldBeEmpty)
+				So(len(values), ShouldEqual, 81)
+			})
+		})
+
+		Convey("When SolveLogical is called with WithForcingChains on sudokus needing forcing chains", func() {
+			used := map[solver.Technique]bool{}
+			for _, grid := range []string{forcingGrid, cellForcingGrid} {
+				_, _, stuck := solver.SolveLogical(context.Background(), grid)
+				values, steps, err := solver.SolveLogical(context.Background(), grid, solver.WithForcingChains())
+				solution, _ := solver.SolveContext(context.Background(), grid)
+				for _, st := range steps {
+					used[st.Technique] = true
+				}
+
+				Convey("Then solve "+grid+", each hypothesis leading to the fact of the step or to a contradiction", func() {
+					So(stuck, ShouldEqual, solver.ErrStuck)
+					So(err, ShouldBeNil)
+					So(values, ShouldResemble, solution)
+
+					for _, st := range steps {
+						if len(st.Branches) == 0 {
+							continue
+						}
+
+						// Placing a digit also removes it from the peers
+						fact := st.Eliminated[0].Digits + " not in " + st.Eliminated[0].Square
+						if len(st.Placed) > 0 {
+							fact = st.Placed[0].Digits + " in " + st.Placed[0].Square
+						}
+						for _, b := range st.Branches {
+							if !strings.HasPrefix(b.Conclusion, "no ") {
+								So(b.Conclusion, ShouldEqual, fact)
+							}
+						}
+					}
+				})
+			}
+
+			Convey("Then the digit, cell and unit forcing chains are all used", func() {
+				So(used[solver.DigitForcingChain], ShouldBeTrue)
+				So(used[solver.CellForcingChain], ShouldBeTrue)
+				So(used[solver.UnitForcingChain], ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveLogical is called with WithForcingChains on a sudoku needing a digit forcing chain", func() {
+			_, steps, _ := solver.SolveLogical(context.Background(), forcingGrid, solver.WithForcingChains())
+
+			var found *solver.Step
+			for i := range steps {
+				if steps[i].Technique == solver.DigitForcingChain {
+					found = &steps[i]
+					break
+				}
+			}
+
+			Convey("Then explain the candidate placed then eliminated, both leading to the same fact", func() {
+				So(found, ShouldNotBeNil)
+				So(found.Cells, ShouldResemble, []string{"I8"})
+				So(found.Digits, ShouldEqual, "5")
+				So(found.Eliminated, ShouldResemble, []solver.Candidates{{Square: "B8", Digits: "5"}})
+				So(found.Branches, ShouldHaveLength, 2)
+				So(found.Branches[1].Hypothesis, ShouldEqual, "5 not in I8")
+				So(found.Branches[1].Placed[0], ShouldResemble, solver.Candidates{Square: "I8", Digits: "7"})
+				So(found.Branches[1].Conclusion, ShouldEqual, "no candidate left in B2")
+
+				data, err := json.Marshal(found.Branches[0])
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `{"hypothesis":"5 in I8","conclusion":"5 not in B8"}`)
+			})
+		})
+
+		Convey("When SolveLogical is called with a square which can not hold any digit", func() {
+			// A9 sees 1 to 8 on its row and 9 on its column
+			_, _, err := solver.SolveLogical(context.Background(), "12345678."+strings.Repeat(".", 71)+"9", lenient)
+
+			Convey("Then return ErrNoSolution", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
+			})
+		})
+
+		Convey("When a step is explained", func() {
+			st := solver.Step{
+				Technique:  solver.NakedPair,
+				Units:      []string{"box A1-C3"},
+				Cells:      []string{"A3", "C3"},
+				Digits:     "39",
+				Eliminated: []solver.Candidates{{Square: "B2", Digits: "3"}, {Square: "C2", Digits: "39"}},
+			}
+			single := solver.Step{
+				Technique:  solver.HiddenSingle,
+				Units:      []string{"row A"},
+				Cells:      []string{"A3"},
+				Digits:     "5",
+				Placed:     []solver.Candidates{{Square: "A3", Digits: "5"}},
+				Eliminated: []solver.Candidates{{Square: "B3", Digits: "5"}, {Square: "C1", Digits: "5"}},
+			}
+
+			finned := solver.Step{
+				Technique:  solver.FinnedXWing,
+				Units:      []string{"row B", "row H", "column 2", "column 7"},
+				Cells:      []string{"B2", "B7", "H2", "H7", "H8"},
+				Digits:     "4",
+				Fins:       []string{"H8"},
+				Eliminated: []solver.Candidates{{Square: "G7", Digits: "4"}},
+			}
+
+			loop := solver.Step{
+				Technique: solver.XCycle,
+				Cells:     []string{"A1", "A5", "C4", "C1", "B2", "B3"},
+				Digits:    "4",
+				Chain: []solver.Link{
+					{From: solver.Node{Digit: "4", Squares: []string{"A1"}}, To: solver.Node{Digit: "4", Squares: []string{"A5"}}, Strong: true},
+					{From: solver.Node{Digit: "4", Squares: []string{"A5"}}, To: solver.Node{Digit: "4", Squares: []string{"C4"}}},
+					{From: solver.Node{Digit: "4", Squares: []string{"C4"}}, To: solver.Node{Digit: "4", Squares: []string{"C1"}}, Strong: true},
+					{From: solver.Node{Digit: "4", Squares: []string{"C1"}}, To: solver.Node{Digit: "4", Squares: []string{"B2", "B3"}}},
+					{From: solver.Node{Digit: "4", Squares: []string{"B2", "B3"}}, To: solver.Node{Digit: "4", Squares: []string{"A1"}}, Strong: true},
+				},
+				Placed: []solver.Candidates{{Square: "A1", Digits: "4"}},
+			}
+
+			nishio := solver.Step{
+				Technique: solver.Nishio,
+				Cells:     []string{"A2"},
+				Digits:    "7",
+				Branches: []solver.Branch{
+					{Hypothesis: "7 in A2", Placed: []solver.Candidates{{Square: "F6", Digits: "6"}, {Square: "C2", Digits: "8"}}, Conclusion: "no candidate left in C2"},
+				},
+				Eliminated: []solver.Candidates{{Square: "A2", Digits: "7"}},
+			}
+
+			Convey("Then tell the technique, where it applies and what it changes", func() {
+				So(st.String(), ShouldEqual, "naked pair in box A1-C3 (39 at A3, C3): removes 3 from B2, 39 from C2")
+				So(loop.String(), ShouldEqual, "x-cycle (4)A1=(4)A5-(4)C4=(4)C1-(4)B2B3=(4)A1: places 4 in A1")
+				So(finned.String(), ShouldEqual, "finned x-wing in row B and row H and column 2 and column 7 (4 at B2, B7, H2, H7, H8 with fins at H8): removes 4 from G7")
+
+				data, err := json.Marshal(loop.Chain[:1])
+				So(err, ShouldBeNil)
+				So(string(data), ShouldEqual, `[{"from":{"digit":"4","squares":["A1"]},"to":{"digit":"4","squares":["A5"]},"strong":true}]`)
+				So(single.String(), ShouldEqual, "hidden single in row A (5 at A3): places 5 in A3; removes 5 from B3 C1")
+				So(nishio.String(), ShouldEqual, "nishio (7 at A2) [7 in A2 => 6 in F6, 8 in C2 => no candidate left in C2]: removes 7 from A2")
+			})
+		})
+	})
+}
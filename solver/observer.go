@@ -0,0 +1,60 @@
+package solver
+
+// Reason tells why a value was eliminated from a square
+type Reason int
+
+const (
+	// ByAssignment means another value was assigned to the square
+	ByAssignment Reason = iota
+	// ByPeer means the value was assigned to a peer of the square
+	ByPeer
+	// ByConstraint means one of the constraints of the sudoku ruled the value out
+	ByConstraint
+)
+
+// String returns the name of the reason
+func (r Reason) String() string {
+	switch r {
+	case ByAssignment:
+		return "assignment"
+	case ByPeer:
+		return "peer"
+	case ByConstraint:
+		return "constraint"
+	}
+	return "unknown"
+}
+
+// Observer is notified of the events happening while a sudoku is solved.
+// Squares are named like the keys of the solved sudoku (A1, B5, D8,...) and digits like its values.
+// While an observer is registered the search runs on a single worker, so events are received in order.
+type Observer interface {
+	// OnAssign is called when a digit is assigned to a square, before the other values are eliminated
+	OnAssign(square, digit string)
+	// OnEliminate is called when a digit is removed from the possible values of a square
+	OnEliminate(square, digit string, reason Reason)
+	// OnGuess is called when the search tries a digit on a square having several possibilities
+	OnGuess(square, digit string, depth int)
+	// OnBacktrack is called when a guess did not lead to a solution
+	OnBacktrack(square, digit string, depth int)
+	// OnSolved is called with the solution found
+	OnSolved(values map[string]string)
+}
+
+// NopObserver ignores every event, embed it to only implement the methods of Observer needed
+type NopObserver struct{}
+
+// OnAssign does nothing
+func (NopObserver) OnAssign(square, digit string) {}
+
+// OnEliminate does nothing
+func (NopObserver) OnEliminate(square, digit string, reason Reason) {}
+
+// OnGuess does nothing
+func (NopObserver) OnGuess(square, digit string, depth int) {}
+
+// OnBacktrack does nothing
+func (NopObserver) OnBacktrack(square, digit string, depth int) {}
+
+// OnSolved does nothing
+func (NopObserver) OnSolved(values map[string]string) {}
@@ -0,0 +1,80 @@
+package solver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recorder counts the events sent by the solver
+type recorder struct {
+	solver.NopObserver
+	assigns, eliminations, guesses, backtracks int64
+	reasons                                    map[solver.Reason]int
+	solved                                     []map[string]string
+}
+
+func (r *recorder) OnAssign(square, digit string) {
+	r.assigns++
+}
+
+func (r *recorder) OnEliminate(square, digit string, reason solver.Reason) {
+	r.eliminations++
+	r.reasons[reason]++
+}
+
+func (r *recorder) OnGuess(square, digit string, depth int) {
+	r.guesses++
+}
+
+func (r *recorder) OnBacktrack(square, digit string, depth int) {
+	r.backtracks++
+}
+
+func (r *recorder) OnSolved(values map[string]string) {
+	r.solved = append(r.solved, values)
+}
+
+func TestSolverObserver(t *testing.T) {
+	Convey("Given a sudoku grid and an observer", t, func() {
+		rec := &recorder{reasons: map[solver.Reason]int{}}
+
+		Convey("When SolveStats is called with the observer registered", func() {
+			values, stats, err := solver.SolveStats(context.Background(), grid, solver.WithObserver(rec))
+
+			Convey("Then every event is received", func() {
+				So(err, ShouldBeNil)
+				So(rec.assigns, ShouldEqual, stats.Assigns)
+				So(rec.eliminations, ShouldEqual, stats.Eliminations)
+				So(rec.guesses, ShouldEqual, stats.Guesses)
+				So(rec.backtracks, ShouldEqual, stats.Backtracks)
+				So(rec.reasons[solver.ByAssignment], ShouldBeGreaterThan, 0)
+				So(rec.reasons[solver.ByPeer], ShouldBeGreaterThan, 0)
+				So(rec.solved, ShouldHaveLength, 1)
+				So(rec.solved[0], ShouldResemble, values)
+			})
+		})
+
+		Convey("When the search is stopped by the node budget right after its first guess", func() {
+			_, stats, err := solver.SolveStats(context.Background(), manySolutionsGrid, solver.WithObserver(rec),
+				solver.WithWorkers(1), solver.WithMaxNodes(1))
+
+			Convey("Then the guess left is not reported as a backtrack", func() {
+				So(err, ShouldEqual, solver.ErrBudgetExceeded)
+				So(rec.guesses, ShouldEqual, 1)
+				So(rec.backtracks, ShouldEqual, 0)
+				So(stats.Backtracks, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the NopObserver is registered", func() {
+			_, err := solver.SolveContext(context.Background(), grid, solver.WithObserver(solver.NopObserver{}))
+
+			Convey("Then show a solved sudoku", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,155 @@
+package solver
+
+import "runtime"
+
+// Option configures how a sudoku is solved
+type Option func(*options)
+
+// ValidationPolicy sets the minimums a grid must satisfy to be solved
+type ValidationPolicy struct {
+	// MinClues is the minimum number of clues given in the grid
+	MinClues int
+	// MinDigits is the minimum number of different digits among the clues
+	MinDigits int
+	// AllowEmpty accepts a grid without any clue whatever the minimums
+	AllowEmpty bool
+}
+
+var (
+	// StrictValidation requires 17 clues and 8 different digits, the minimums for a classic sudoku to have
+	// a unique solution. It is the default policy of classic sudokus.
+	StrictValidation = ValidationPolicy{MinClues: 17, MinDigits: 8}
+	// LenientValidation accepts any grid, including an empty one
+	LenientValidation = ValidationPolicy{AllowEmpty: true}
+)
+
+// options holds the settings applied by the Option functions
+type options struct {
+	// geometry is the shape of the sudoku
+	geometry *Geometry
+	// constraints are the rules applied on top of the classic units
+	constraints []Constraint
+	// variant adds the constraints of a kind of sudoku
+	variant Variant
+	// validation is the policy the grid must satisfy, the default one of the geometry if nil
+	validation *ValidationPolicy
+	// maxNodes is the maximum number of search nodes explored, 0 means unlimited
+	maxNodes int64
+	// workers is the number of goroutines searching concurrently
+	workers int
+	// deterministic makes the search always return the lexicographically first solution
+	deterministic bool
+	// observer is notified of the solver events, if any
+	observer Observer
+	// uniqueness lets the logical solver assume the sudoku has a single solution
+	uniqueness bool
+	// forcing lets the logical solver make hypotheses once the other techniques are stuck
+	forcing bool
+}
+
+// WithMaxNodes stops the search once n nodes have been explored.
+// The solver then returns the candidates of the grid after propagation along with ErrBudgetExceeded,
+// CountSolutionsContext the number of solutions found so far.
+func WithMaxNodes(n int64) Option {
+	return func(o *options) {
+		o.maxNodes = n
+	}
+}
+
+// WithWorkers sets the number of goroutines searching concurrently, GOMAXPROCS by default.
+// A value lower than 1 keeps the default.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithDeterministic makes the search always return the lexicographically first solution
+// of a sudoku having several, whatever the number of workers.
+func WithDeterministic() Option {
+	return func(o *options) {
+		o.deterministic = true
+	}
+}
+
+// WithGeometry sets the shape of the sudoku, Classic by default
+func WithGeometry(g *Geometry) Option {
+	return func(o *options) {
+		if g != nil {
+			o.geometry = g
+		}
+	}
+}
+
+// WithConstraints adds rules the solution must follow on top of the rows, columns and boxes
+func WithConstraints(cs ...Constraint) Option {
+	return func(o *options) {
+		o.constraints = append(o.constraints, cs...)
+	}
+}
+
+// WithVariant solves the sudoku as the given variant, its constraints come in addition to the ones set
+// with WithConstraints. An unknown variant makes the solving fail with an *UnknownVariantError.
+func WithVariant(v Variant) Option {
+	return func(o *options) {
+		o.variant = v
+	}
+}
+
+// WithValidation sets the policy the grid must satisfy. By default classic sudokus use StrictValidation
+// and other geometries require all the symbols but one to be given.
+func WithValidation(p ValidationPolicy) Option {
+	return func(o *options) {
+		o.validation = &p
+	}
+}
+
+// WithObserver registers an observer notified of the events happening while the sudoku is solved.
+// The search then runs on a single worker so that the events are received in order.
+func WithObserver(obs Observer) Option {
+	return func(o *options) {
+		o.observer = obs
+	}
+}
+
+// WithUniqueness lets the logical solver use the techniques assuming the sudoku has a single solution, the unique
+// and avoidable rectangles and the BUG+1. They are left out of the sudokus having constraints other than units.
+func WithUniqueness() Option {
+	return func(o *options) {
+		o.uniqueness = true
+	}
+}
+
+// WithForcingChains lets the logical solver make hypotheses once the other techniques are stuck: Nishio and the
+// digit, cell and unit forcing chains propagate them with the rules of the sudoku, constraints included, until they
+// lead to a contradiction or all to the same digit placed or eliminated.
+func WithForcingChains() Option {
+	return func(o *options) {
+		o.forcing = true
+	}
+}
+
+// newOptions apply opts over the default options
+func newOptions(opts []Option) *options {
+	o := &options{
+		geometry: Classic,
+		workers:  runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.observer != nil {
+		o.workers = 1
+	}
+	return o
+}
+
+// policy returns the validation policy the grid must satisfy
+func (o *options) policy() ValidationPolicy {
+	if o.validation != nil {
+		return *o.validation
+	}
+	return o.geometry.defaultPolicy()
+}
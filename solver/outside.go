@@ -0,0 +1,335 @@
+package solver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OutsideKind names the kind of a clue given outside of the grid
+type OutsideKind string
+
+const (
+	// Sandwich is the sum of the values between the 1 and the biggest digit of the row or the column
+	Sandwich OutsideKind = "sandwich"
+	// Skyscraper is the number of values seen from the clue, a value hiding the smaller ones behind it
+	Skyscraper OutsideKind = "skyscraper"
+	// XSum is the sum of the first X values seen from the clue, X being the first of them
+	XSum OutsideKind = "xsum"
+)
+
+// OutsideClue is a clue given outside of the grid, on one side of a row or a column.
+// Position is the side, L(eft), R(ight), T(op) or B(ottom), followed by the number of the row or the column
+// counted from 1: "L1" looks at row A from its left, "B3" at column 3 from its bottom.
+type OutsideClue struct {
+	Kind     OutsideKind `json:"kind"`
+	Position string      `json:"position"`
+	Value    int         `json:"value"`
+}
+
+// String returns the clue in the notation of ParsePuzzle, like "sandwich:L1=10"
+func (c OutsideClue) String() string {
+	return fmt.Sprintf("%s:%s=%d", c.Kind, c.Position, c.Value)
+}
+
+// Outside is the constraint of the clues given outside of the grid: sandwich sums, skyscrapers and X-sums
+type Outside struct {
+	NopConstraint
+	Clues []OutsideClue `json:"clues"`
+}
+
+// lineRule is the automaton checking a clue while reading the values of a row or a column from the clue.
+// Its states are numbered from 0, the state before reading any value, the values from 1 to the size of the sudoku.
+type lineRule interface {
+	// states returns the number of states
+	states() int
+	// next returns the state after reading v, false if no sequence of values going through it satisfies the clue
+	next(st, v int) (int, bool)
+	accept(st int) bool
+}
+
+// outsideLine is a row or a column read from a clue
+type outsideLine struct {
+	squares []int
+	rule    lineRule
+}
+
+// outside is the Outside constraint compiled for a geometry
+type outside struct {
+	lines []outsideLine
+	// linesOf holds the indexes of the lines of each square
+	linesOf [][]int
+}
+
+// Compile find the squares seen from each clue. Return an *OutsideClueError if a clue is not valid.
+func (o Outside) Compile(g *Geometry) (Constraint, error) {
+	oc := &outside{linesOf: make([][]int, len(g.squares))}
+
+	for _, c := range o.Clues {
+		squares, err := g.line(c.Position)
+		if err != nil {
+			return nil, &OutsideClueError{Clue: c.String(), Reason: err.Error()}
+		}
+
+		var rule lineRule
+		total := g.size * (g.size + 1) / 2
+		switch c.Kind {
+		case Sandwich:
+			if c.Value < 0 || c.Value > total-1-g.size {
+				return nil, &OutsideClueError{Clue: c.String(), Reason: "the sum can not be reached"}
+			}
+			rule = sandwich{size: g.size, sum: c.Value}
+		case Skyscraper:
+			if c.Value < 1 || c.Value > g.size {
+				return nil, &OutsideClueError{Clue: c.String(), Reason: "the count can not be reached"}
+			}
+			rule = skyscraper{size: g.size, count: c.Value}
+		case XSum:
+			if c.Value < 1 || c.Value > total {
+				return nil, &OutsideClueError{Clue: c.String(), Reason: "the sum can not be reached"}
+			}
+			rule = xsum{size: g.size, sum: c.Value}
+		default:
+			return nil, &OutsideClueError{Clue: c.String(), Reason: "unknown kind of clue"}
+		}
+
+		for _, s := range squares {
+			oc.linesOf[s] = append(oc.linesOf[s], len(oc.lines))
+		}
+		oc.lines = append(oc.lines, outsideLine{squares: squares, rule: rule})
+	}
+
+	return oc, nil
+}
+
+// Line returns the squares of a row or a column in the order they are seen from the position of a clue
+func (g *Geometry) line(position string) ([]int, error) {
+	if len(g.grids) > 1 {
+		return nil, fmt.Errorf("the clues outside of the grid need a single grid")
+	}
+	if len(position) < 2 {
+		return nil, fmt.Errorf("unknown position %q", position)
+	}
+	n, err := strconv.Atoi(position[1:])
+	if err != nil || n < 1 || n > g.size {
+		return nil, fmt.Errorf("unknown position %q", position)
+	}
+
+	res := make([]int, g.size)
+	for i := range res {
+		switch strings.ToUpper(position[:1]) {
+		case "L":
+			res[i] = (n-1)*g.size + i
+		case "R":
+			res[i] = (n-1)*g.size + g.size - 1 - i
+		case "T":
+			res[i] = i*g.size + n - 1
+		case "B":
+			res[i] = (g.size-1-i)*g.size + n - 1
+		default:
+			return nil, fmt.Errorf("unknown position %q", position)
+		}
+	}
+	return res, nil
+}
+
+// Squares returns the squares of the lines
+func (o *outside) Squares(g *Geometry) []int {
+	var res []int
+	for s, lines := range o.linesOf {
+		if len(lines) > 0 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Propagate restrict the lines of square s, every line before the clues are placed
+func (o *outside) Propagate(b *Board, s int) bool {
+	if s < 0 {
+		for i := range o.lines {
+			if !restrict(b, &o.lines[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, i := range o.linesOf[s] {
+		if !restrict(b, &o.lines[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Restrict keeps the candidates of each square of the line which are part of a sequence of candidates
+// satisfying its rule. The values of a line being distinct, the sequences repeating a value are not ruled out:
+// the rule is only checked exactly once the values are placed.
+// Return false if a contradiction is detected.
+func restrict(b *Board, l *outsideLine) bool {
+	// The states reached after reading the candidates of each square, seen records the last layer
+	// each state was reached in
+	seen := make([]int, l.rule.states())
+	reached := make([][]int, len(l.squares)+1)
+	reached[0] = []int{0}
+	for i, s := range l.squares {
+		for _, st := range reached[i] {
+			for v := b.Candidates(s); v != 0; v &= v - 1 {
+				if next, ok := l.rule.next(st, lowest(v)); ok && seen[next] != i+1 {
+					seen[next] = i + 1
+					reached[i+1] = append(reached[i+1], next)
+				}
+			}
+		}
+	}
+
+	// Walk back from the accepted states, keeping the candidates leading to them
+	alive, before := make([]bool, len(seen)), make([]bool, len(seen))
+	for _, st := range reached[len(l.squares)] {
+		alive[st] = l.rule.accept(st)
+	}
+	kept := make([]uint32, len(l.squares))
+	for i := len(l.squares) - 1; i >= 0; i-- {
+		for _, st := range reached[i] {
+			for v := b.Candidates(l.squares[i]); v != 0; v &= v - 1 {
+				if next, ok := l.rule.next(st, lowest(v)); ok && alive[next] {
+					before[st] = true
+					kept[i] |= v & -v
+				}
+			}
+		}
+		for _, st := range reached[i+1] {
+			alive[st] = false
+		}
+		alive, before = before, alive
+	}
+
+	for i, s := range l.squares {
+		if !b.Keep(s, kept[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sandwich reads the line with the state phase*(sum+1)+sum so far: before the crusts, the 1 and the biggest digit,
+// the phase is 0, between them it is 1 or 2 depending on the first crust read, after them it is 3
+type sandwich struct {
+	size, sum int
+}
+
+func (r sandwich) states() int {
+	return 4 * (r.sum + 1)
+}
+
+func (r sandwich) next(st, v int) (int, bool) {
+	phase, sum := st/(r.sum+1), st%(r.sum+1)
+	crust := 0
+	if v == 1 {
+		crust = 1
+	} else if v == r.size {
+		crust = 2
+	}
+
+	switch {
+	case phase == 0 && crust > 0:
+		return crust * (r.sum + 1), true
+	case phase == 0 || phase == 3:
+		return st, crust == 0
+	case crust > 0:
+		return 3*(r.sum+1) + sum, crust != phase && sum == r.sum
+	case sum+v > r.sum:
+		return 0, false
+	}
+	return st + v, true
+}
+
+func (r sandwich) accept(st int) bool {
+	return st/(r.sum+1) == 3
+}
+
+// skyscraper reads the line with the state tallest*(count+1)+seen, the tallest value and the number of values seen
+type skyscraper struct {
+	size, count int
+}
+
+func (r skyscraper) states() int {
+	return (r.size + 1) * (r.count + 1)
+}
+
+func (r skyscraper) next(st, v int) (int, bool) {
+	tallest, seen := st/(r.count+1), st%(r.count+1)
+	switch {
+	case v <= tallest:
+		return st, true
+	case seen == r.count:
+		return 0, false
+	}
+	return v*(r.count+1) + seen + 1, true
+}
+
+func (r skyscraper) accept(st int) bool {
+	return st%(r.count+1) == r.count
+}
+
+// xsum reads the line with the state (left+1)*(sum+1)+sum so far, left being the number of values left to add
+// once the first value is read
+type xsum struct {
+	size, sum int
+}
+
+func (r xsum) states() int {
+	return (r.size + 1) * (r.sum + 1)
+}
+
+func (r xsum) next(st, v int) (int, bool) {
+	left, sum := st/(r.sum+1)-1, st%(r.sum+1)
+	switch {
+	case left < 0:
+		return v*(r.sum+1) + v, v <= r.sum
+	case left == 0:
+		return st, true
+	case sum+v > r.sum:
+		return 0, false
+	}
+	return left*(r.sum+1) + sum + v, true
+}
+
+func (r xsum) accept(st int) bool {
+	return st == r.sum+1+r.sum
+}
+
+// ParsePuzzle split a puzzle written in the compact notation into its grid and the constraints of its clues.
+// The grid comes first, followed by groups of clues of a kind separated by a |, like
+// "<81 characters>|sandwich:L1=10,T3=0|skyscraper:T1=3,R2=4|xsum:L5=25".
+// Return an *OutsideClueError if a clue can not be read. The other functions only take the grid: given a puzzle
+// in this notation, they return an *OutsideClueError pointing to ParsePuzzle.
+func ParsePuzzle(puzzle string) (string, []Constraint, error) {
+	parts := strings.Split(puzzle, "|")
+
+	var clues []OutsideClue
+	for _, group := range parts[1:] {
+		kv := strings.SplitN(strings.TrimSpace(group), ":", 2)
+		if len(kv) != 2 {
+			return "", nil, &OutsideClueError{Clue: group, Reason: "expected the kind of the clues followed by :"}
+		}
+		kind := OutsideKind(strings.ToLower(strings.TrimSpace(kv[0])))
+		for _, clue := range strings.Split(kv[1], ",") {
+			pv := strings.SplitN(strings.TrimSpace(clue), "=", 2)
+			if len(pv) != 2 {
+				return "", nil, &OutsideClueError{Clue: clue, Reason: "expected a position and a value joined by ="}
+			}
+			n, err := strconv.Atoi(pv[1])
+			if err != nil {
+				return "", nil, &OutsideClueError{Clue: clue, Reason: "the value is not a number"}
+			}
+			clues = append(clues, OutsideClue{Kind: kind, Position: strings.ToUpper(pv[0]), Value: n})
+		}
+	}
+
+	if len(clues) == 0 {
+		return parts[0], nil, nil
+	}
+	return parts[0], []Constraint{Outside{Clues: clues}}, nil
+}
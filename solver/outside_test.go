@@ -0,0 +1,105 @@
+package solver_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// The outside clues of the solution of the killer sudoku, in the compact notation
+const sandwichPuzzle = "...3............................71...........................7...................|sandwich:L1=16,L2=13,L3=29,L4=6,L5=0,L6=0,L7=25,L8=0,L9=32,T1=25,T2=10,T3=6,T4=16,T5=4,T6=30,T7=3,T8=29,T9=10"
+const skyscraperPuzzle = ".......2........4..5........................2.........2............9...4.........|skyscraper:L1=3,L2=3,L3=1,L4=2,L5=2,L6=4,L7=3,L8=3,L9=4,T1=3,T2=4,T3=3,T4=3,T5=3,T6=1,T7=2,T8=6,T9=3,R1=3,R2=2,R3=4,R4=1,R5=6,R6=2,R7=3,R8=3,R9=2,B1=5,B2=4,B3=2,B4=2,B5=2,B6=5,B7=4,B8=1,B9=4"
+const xsumPuzzle = ".................................1...........................7...................|xsum:L1=15,L2=25,L3=45,L4=36,L5=40,L6=13,L7=10,L8=26,L9=1,T1=27,T2=1,T3=38,T4=11,T5=25,T6=45,T7=43,T8=6,T9=29,R1=30,R2=36,R3=23,R4=45,R5=5,R6=42,R7=1,R8=19,R9=14,B1=1,B2=36,B3=22,B4=42,B5=34,B6=17,B7=8,B8=45,B9=8"
+
+func TestOutsideClues(t *testing.T) {
+	Convey("Given puzzles with clues outside of the grid and a solver", t, func() {
+		lenient := solver.WithValidation(solver.LenientValidation)
+
+		for _, puzzle := range []string{sandwichPuzzle, skyscraperPuzzle, xsumPuzzle} {
+			kind := puzzle[strings.Index(puzzle, "|")+1 : strings.Index(puzzle, ":")]
+
+			Convey("When SolveContext is called with the clues of a "+kind+" sudoku", func() {
+				grid, cs, err := solver.ParsePuzzle(puzzle)
+				So(err, ShouldBeNil)
+
+				values, err := solver.SolveContext(context.Background(), grid, lenient, solver.WithConstraints(cs...))
+				unique, _ := solver.IsUnique(grid, lenient, solver.WithConstraints(cs...))
+
+				Convey("Then show the solved sudoku, unique with its few clues", func() {
+					So(err, ShouldBeNil)
+					So(solver.Classic.Flatten(values), ShouldEqual, killerSolution)
+					So(unique, ShouldBeTrue)
+				})
+			})
+		}
+
+		Convey("When a puzzle without outside clues is parsed", func() {
+			grid, cs, err := solver.ParsePuzzle(killerSolution)
+
+			Convey("Then return the grid alone", func() {
+				So(err, ShouldBeNil)
+				So(grid, ShouldEqual, killerSolution)
+				So(cs, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When SolveContext is called with the sandwiches of a row summing its digits between 1 and 9", func() {
+			values, err := solver.SolveContext(context.Background(), strings.Repeat(".", 81), lenient,
+				solver.WithConstraints(solver.Outside{Clues: []solver.OutsideClue{{Kind: solver.Sandwich, Position: "R5", Value: 35}}}))
+
+			Convey("Then the crusts are at both ends of the row", func() {
+				So(err, ShouldBeNil)
+				row := solver.Classic.Flatten(values)[36:45]
+				So(row[0:1]+row[8:9], ShouldBeIn, []string{"19", "91"})
+			})
+		})
+
+		Convey("When a puzzle with clues which can not be read is parsed", func() {
+			_, _, errKind := solver.ParsePuzzle(killerSolution + "|sandwich")
+			_, _, errValue := solver.ParsePuzzle(killerSolution + "|xsum:L1=ten")
+
+			Convey("Then return an OutsideClueError", func() {
+				var clueErr *solver.OutsideClueError
+				So(errors.As(errKind, &clueErr), ShouldBeTrue)
+				So(clueErr.Clue, ShouldEqual, "sandwich")
+				So(errors.As(errValue, &clueErr), ShouldBeTrue)
+				So(clueErr.Clue, ShouldEqual, "L1=ten")
+			})
+		})
+
+		Convey("When Solve is called with a puzzle in the compact notation", func() {
+			_, err := solver.Solve(killerSolution + "|sandwich:L1=10")
+
+			Convey("Then return an OutsideClueError pointing to ParsePuzzle", func() {
+				var clueErr *solver.OutsideClueError
+				So(errors.As(err, &clueErr), ShouldBeTrue)
+				So(err.Error(), ShouldEqual, `Invalid outside clue "sandwich:L1=10": the clues after the grid must be read with ParsePuzzle`)
+			})
+		})
+
+		Convey("When SolveContext is called with clues which are not valid", func() {
+			solve := func(clue solver.OutsideClue) error {
+				_, err := solver.SolveContext(context.Background(), strings.Repeat(".", 81), lenient,
+					solver.WithConstraints(solver.Outside{Clues: []solver.OutsideClue{clue}}))
+				return err
+			}
+			errPosition := solve(solver.OutsideClue{Kind: solver.Skyscraper, Position: "X1", Value: 3})
+			errValue := solve(solver.OutsideClue{Kind: solver.Sandwich, Position: "T1", Value: 36})
+			errKind := solve(solver.OutsideClue{Kind: "little-killer", Position: "L1", Value: 3})
+
+			Convey("Then return an OutsideClueError naming the clue", func() {
+				var clueErr *solver.OutsideClueError
+				So(errors.As(errPosition, &clueErr), ShouldBeTrue)
+				So(clueErr.Error(), ShouldEqual, `Invalid outside clue "skyscraper:X1=3": unknown position "X1"`)
+				So(errors.As(errValue, &clueErr), ShouldBeTrue)
+				So(clueErr.Error(), ShouldEqual, `Invalid outside clue "sandwich:T1=36": the sum can not be reached`)
+				So(errors.As(errKind, &clueErr), ShouldBeTrue)
+				So(clueErr.Error(), ShouldEqual, `Invalid outside clue "little-killer:L1=3": unknown kind of clue`)
+			})
+		})
+	})
+}
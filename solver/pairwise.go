@@ -0,0 +1,142 @@
+package solver
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Relation tells whether the values a and b, from 1 to the size of the sudoku, can be held by the first and
+// the second square of a pair
+type Relation func(a, b int) bool
+
+// Pairwise is the constraint holding a relation between the values of pairs of squares, given by their index.
+// The candidates of each square of a pair are kept consistent with the ones of the other square.
+type Pairwise struct {
+	NopConstraint
+	Pairs    [][2]int
+	Relation Relation
+}
+
+// pairwise is the Pairwise constraint compiled for a geometry, each pair having its own relation
+type pairwise struct {
+	pairs [][2]int
+	// relations holds the index in tables of the relation of each pair
+	relations []int
+	tables    []relationTable
+	// pairsOf holds the indexes of the pairs of each square
+	pairsOf [][]int
+}
+
+// relationTable holds, for each value of the first square, the values the second square can hold, and
+// for each value of the second square, the values the first square can hold
+type relationTable struct {
+	forward, backward []uint32
+}
+
+// newRelationTable tabulate the relation for the symbols of the geometry
+func newRelationTable(g *Geometry, rel Relation) relationTable {
+	t := relationTable{forward: make([]uint32, g.size), backward: make([]uint32, g.size)}
+	for a := 1; a <= g.size; a++ {
+		for b := 1; b <= g.size; b++ {
+			if rel(a, b) {
+				t.forward[a-1] |= 1 << uint(b-1)
+				t.backward[b-1] |= 1 << uint(a-1)
+			}
+		}
+	}
+	return t
+}
+
+// Compile tabulate the relation for the symbols of the geometry
+func (p Pairwise) Compile(g *Geometry) (Constraint, error) {
+	if p.Relation == nil {
+		return nil, fmt.Errorf("Invalid constraint %T: the relation is missing", p)
+	}
+
+	pc := &pairwise{pairsOf: make([][]int, len(g.squares))}
+	pc.tables = append(pc.tables, newRelationTable(g, p.Relation))
+	for _, pr := range p.Pairs {
+		if !pc.add(g, pr, 0) {
+			return nil, fmt.Errorf("Invalid constraint %T: pair %v is not made of two squares of the grid", p, pr)
+		}
+	}
+
+	return pc, nil
+}
+
+// Add a pair holding the relation of index rel, return false if the pair is not made of squares of the grid
+func (p *pairwise) add(g *Geometry, pr [2]int, rel int) bool {
+	for _, s := range pr {
+		if s < 0 || s >= len(g.squares) {
+			return false
+		}
+	}
+
+	for _, s := range pr {
+		p.pairsOf[s] = append(p.pairsOf[s], len(p.pairs))
+	}
+	p.pairs = append(p.pairs, pr)
+	p.relations = append(p.relations, rel)
+	return true
+}
+
+// Squares returns the squares of the pairs
+func (p *pairwise) Squares(g *Geometry) []int {
+	var res []int
+	for s, pairs := range p.pairsOf {
+		if len(pairs) > 0 {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Propagate revise the pairs of square s, every pair before the clues are placed
+func (p *pairwise) Propagate(b *Board, s int) bool {
+	if s < 0 {
+		for i := range p.pairs {
+			if !p.revise(b, i) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, i := range p.pairsOf[s] {
+		if !p.revise(b, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Revise keeps the candidates of each square of the pair i supported by a candidate of the other square.
+// Return false if a contradiction is detected.
+func (p *pairwise) revise(b *Board, i int) bool {
+	pr, t := p.pairs[i], p.tables[p.relations[i]]
+	return b.Keep(pr[1], supported(b.Candidates(pr[0]), t.forward)) &&
+		b.Keep(pr[0], supported(b.Candidates(pr[1]), t.backward))
+}
+
+// Supported returns the values allowed by one of the candidates according to the table
+func supported(candidates uint32, table []uint32) uint32 {
+	var res uint32
+	for ; candidates != 0; candidates &= candidates - 1 {
+		res |= table[bits.TrailingZeros32(candidates)]
+	}
+	return res
+}
+
+// NonConsecutive is the constraint of the non-consecutive sudoku: squares adjacent on a row or a column
+// do not hold consecutive values
+type NonConsecutive struct {
+	NopConstraint
+}
+
+// Compile the relation on every pair of adjacent squares
+func (NonConsecutive) Compile(g *Geometry) (Constraint, error) {
+	return Pairwise{
+		Pairs:    moves(g, [][2]int{{0, 1}, {1, 0}}),
+		Relation: func(a, b int) bool { return a-b != 1 && b-a != 1 },
+	}.Compile(g)
+}
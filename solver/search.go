@@ -0,0 +1,335 @@
+package solver
+
+import (
+	"context"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// tasksPerWorker is the number of subtrees the top of the search tree is split into for each worker
+	tasksPerWorker = 8
+	// maxSplitDepth bounds the number of levels expanded when splitting the search tree
+	maxSplitDepth = 4
+)
+
+// Searcher holds the state shared by every worker of a search
+type searcher struct {
+	// nodes is the number of nodes explored so far, updated atomically
+	nodes    int64
+	maxNodes int64
+	// exceeded is set to 1 once the node budget has been spent
+	exceeded int32
+
+	workers       int
+	deterministic bool
+	rules         *rules
+	observer      Observer
+	// best is the index of the first task known to lead to a solution, updated atomically
+	best int64
+
+	// stats gathers the work done by the workers once they are over
+	mu    sync.Mutex
+	stats Stats
+
+	// stop cancels the whole search
+	stop context.CancelFunc
+}
+
+// Task is a subtree of the search tree explored by a single worker
+type task struct {
+	values board
+	depth  int
+}
+
+// newSearcher create a searcher from the solving options and the rules of the sudoku, stop being called
+// to cancel the whole search
+func newSearcher(o *options, r *rules, stop context.CancelFunc) *searcher {
+	return &searcher{
+		maxNodes:      o.maxNodes,
+		workers:       o.workers,
+		deterministic: o.deterministic,
+		rules:         r,
+		observer:      o.observer,
+		stop:          stop,
+	}
+}
+
+// Search using depth-first search and propagation, try all possible values.
+// The top levels of the search tree are split into tasks, kept in depth-first order, which are handed
+// out to a bounded number of workers. Every worker is joined before returning.
+// Return nil if no task leads to a solution.
+func (sr *searcher) search(ctx context.Context, values board) board {
+	tasks := sr.split(values)
+	results := make([]board, len(tasks))
+	sr.best = int64(len(tasks))
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < sr.workers && w < len(tasks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			p := sr.newPropagator()
+			defer sr.merge(p)
+
+			for {
+				i := atomic.AddInt64(&next, 1)
+				// Tasks are handed out in order, once a task is past the best one so are the next ones
+				if i >= int64(len(tasks)) || i > atomic.LoadInt64(&sr.best) {
+					return
+				}
+				if res := sr.dfs(ctx.Done(), p, tasks[i].values, i, tasks[i].depth); res != nil {
+					results[i] = res
+					sr.found(i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sr.stats.Nodes = sr.nodes
+	if best := sr.best; best < int64(len(tasks)) {
+		return results[best]
+	}
+	return nil
+}
+
+// Split expands the top levels of the search tree, level by level to keep the depth-first order,
+// until there are enough subtrees to keep every worker busy.
+func (sr *searcher) split(values board) []task {
+	tasks := []task{{values: values}}
+	if sr.workers < 2 {
+		return tasks
+	}
+
+	p := sr.newPropagator()
+	defer sr.merge(p)
+
+	for depth := 0; depth < maxSplitDepth && len(tasks) < sr.workers*tasksPerWorker; depth++ {
+		next := make([]task, 0, len(tasks)*2)
+		expanded := false
+		for _, t := range tasks {
+			sq := sr.selectSquare(t.values)
+			if sq < 0 {
+				next = append(next, t)
+				continue
+			}
+
+			expanded = true
+			sr.nodes++
+			for v := t.values[sq]; v != 0; v &= v - 1 {
+				p.stats.Guesses++
+				newValues := t.values.clone()
+				if p.assign(newValues, sq, v&-v) {
+					next = append(next, task{values: newValues, depth: depth + 1})
+				} else {
+					p.stats.Backtracks++
+				}
+			}
+		}
+
+		tasks = next
+		if !expanded {
+			break
+		}
+	}
+
+	return tasks
+}
+
+// Dfs search the subtree of the given task one branch after the other.
+// Return nil if the subtree has no solution or if the search of the task was stopped.
+func (sr *searcher) dfs(done <-chan struct{}, p *propagator, values board, task int64, depth int) board {
+	if sr.stopped(done, task) {
+		return nil
+	}
+
+	if n := atomic.AddInt64(&sr.nodes, 1); sr.maxNodes > 0 && n > sr.maxNodes {
+		atomic.StoreInt32(&sr.exceeded, 1)
+		sr.stop()
+		return nil
+	}
+
+	if depth > p.stats.MaxDepth {
+		p.stats.MaxDepth = depth
+	}
+
+	// Check if there is only one remaining possibility in every square
+	// If true, return the solved sudoku
+	sq := sr.selectSquare(values)
+	if sq < 0 {
+		if p.observer != nil {
+			p.observer.OnSolved(p.g.toMap(values))
+		}
+		return values
+	}
+
+	for v := values[sq]; v != 0; v &= v - 1 {
+		p.stats.Guesses++
+		if p.observer != nil {
+			p.observer.OnGuess(p.g.squares[sq], p.g.digit(v&-v), depth+1)
+		}
+
+		newValues := values.clone()
+		if p.assign(newValues, sq, v&-v) {
+			if res := sr.dfs(done, p, newValues, task, depth+1); res != nil {
+				return res
+			}
+		}
+
+		// The guess was not proven wrong when the search was stopped
+		if sr.stopped(done, task) {
+			return nil
+		}
+		p.stats.Backtracks++
+		if p.observer != nil {
+			p.observer.OnBacktrack(p.g.squares[sq], p.g.digit(v&-v), depth+1)
+		}
+	}
+	return nil
+}
+
+// Stopped report whether the search of task must stop: the search was cancelled, the node budget was spent,
+// or a solution was already found in a task coming first
+func (sr *searcher) stopped(done <-chan struct{}, task int64) bool {
+	select {
+	case <-done:
+		return true
+	default:
+	}
+	return sr.deterministic && task > atomic.LoadInt64(&sr.best)
+}
+
+// NewPropagator create the propagator of a worker
+func (sr *searcher) newPropagator() *propagator {
+	return &propagator{g: sr.rules.g, r: sr.rules, observer: sr.observer}
+}
+
+// Merge add the work done by the propagator of a worker to the search stats
+func (sr *searcher) merge(p *propagator) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.stats.add(p.stats)
+}
+
+// Found record that task leads to a solution. Unless the search is deterministic,
+// the first solution found ends the search.
+func (sr *searcher) found(task int64) {
+	for {
+		best := atomic.LoadInt64(&sr.best)
+		if task >= best || atomic.CompareAndSwapInt64(&sr.best, best, task) {
+			break
+		}
+	}
+
+	if !sr.deterministic {
+		sr.stop()
+	}
+}
+
+// SelectSquare chose the square to branch on. A deterministic search branches on the first unfilled
+// square so that the depth-first order is the lexicographic order of the solutions.
+func (sr *searcher) selectSquare(values board) int {
+	if sr.deterministic {
+		return firstSquare(values)
+	}
+	return selectSquare(values)
+}
+
+// Enumerate send every solution found under values to ch, one branch after the other.
+// Return false if ctx is done before the enumeration is over.
+func enumerate(ctx context.Context, p *propagator, values board, ch chan<- map[string]string) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	sq := selectSquare(values)
+	if sq < 0 {
+		select {
+		case ch <- p.g.toMap(values):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for v := values[sq]; v != 0; v &= v - 1 {
+		newValues := values.clone()
+		if p.assign(newValues, sq, v&-v) && !enumerate(ctx, p, newValues, ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectSquare chose the first unfilled square with the fewest possibilities.
+// Return -1 if every square has only one remaining possibility.
+func selectSquare(values board) int {
+	min := maxSize + 1
+	sq := -1
+	for s, v := range values {
+		l := bits.OnesCount32(v)
+		if l > 1 && l < min {
+			sq = s
+			min = l
+		}
+	}
+	return sq
+}
+
+// FirstSquare chose the first unfilled square.
+// Return -1 if every square has only one remaining possibility.
+func firstSquare(values board) int {
+	for s, v := range values {
+		if v&(v-1) != 0 {
+			return s
+		}
+	}
+	return -1
+}
+
+// Counter counts the solutions of a sudoku until ctx is done or maxNodes nodes have been explored
+type counter struct {
+	ctx      context.Context
+	p        *propagator
+	maxNodes int64
+	nodes    int64
+	// err is set to ctx.Err() or ErrBudgetExceeded once the count is stopped
+	err error
+}
+
+// Count explores every branch of the search tree and stops once limit solutions are found.
+// Return the solutions found so far if the count is stopped.
+func (c *counter) count(values board, limit int) int {
+	if c.err != nil {
+		return 0
+	}
+	if c.err = c.ctx.Err(); c.err != nil {
+		return 0
+	}
+	if c.nodes++; c.maxNodes > 0 && c.nodes > c.maxNodes {
+		c.err = ErrBudgetExceeded
+		return 0
+	}
+
+	sq := selectSquare(values)
+	if sq < 0 {
+		return 1
+	}
+
+	n := 0
+	for v := values[sq]; v != 0 && c.err == nil; v &= v - 1 {
+		newValues := values.clone()
+		if c.p.assign(newValues, sq, v&-v) {
+			n += c.count(newValues, limit-n)
+		}
+		if limit > 0 && n >= limit {
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,34 @@
+package solver
+
+import "time"
+
+// Stats describe the effort spent solving a sudoku
+type Stats struct {
+	// Nodes is the number of nodes of the search tree explored
+	Nodes int64 `json:"nodes"`
+	// Guesses is the number of values tried on squares having several possibilities
+	Guesses int64 `json:"guesses"`
+	// Backtracks is the number of guesses which did not lead to a solution
+	Backtracks int64 `json:"backtracks"`
+	// Assigns is the number of calls to assign, including the ones made while parsing the grid
+	Assigns int64 `json:"assigns"`
+	// Eliminations is the number of values removed from the squares possibilities
+	Eliminations int64 `json:"eliminations"`
+	// MaxDepth is the depth of the deepest node of the search tree explored
+	MaxDepth int `json:"max_depth"`
+	// Duration is the wall time spent solving the sudoku, in nanoseconds once encoded to JSON
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// add the counters of other to the stats
+func (s *Stats) add(other Stats) {
+	s.Nodes += other.Nodes
+	s.Guesses += other.Guesses
+	s.Backtracks += other.Backtracks
+	s.Assigns += other.Assigns
+	s.Eliminations += other.Eliminations
+	if other.MaxDepth > s.MaxDepth {
+		s.MaxDepth = other.MaxDepth
+	}
+	s.Duration += other.Duration
+}
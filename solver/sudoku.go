@@ -1,308 +1,294 @@
 package solver
 
 import (
-	"errors"
-	"fmt"
-	"strings"
+	"context"
+	"math/bits"
+	"sync/atomic"
+	"time"
 )
 
-const digits string = "123456789"
-const rows string = "ABCDEFGHI"
-const cols string = digits
+// Board holds the possible values of each square as a bitmask, bit d-1 being set when the d-th symbol is possible.
+// Squares are indexed row by row: 0 is A1, 1 is A2, ..., 80 is I9 for a classic sudoku.
+type board []uint32
 
-var squares = cross(rows, cols)
-var unitlist = createUnitList(rows, cols)
-var units = createUnits(squares, unitlist)
-var peers = createPeers(units)
-
-// Cross the product of each elements in strings A and B together
-func cross(A, B string) []string {
-	res := make([]string, len(A)*len(B))
-
-	i := 0
-	for _, a := range A {
-		for _, b := range B {
-			res[i] = string(a) + string(b)
-			i++
-		}
-	}
-
-	return res
+// Clone the board so that the copy can be changed on its own
+func (values board) clone() board {
+	return append(board(nil), values...)
 }
 
-// CreateUnitList list the 20 peers of each sudoku squares
-func createUnitList(rows, cols string) [][]string {
-	res := make([][]string, len(rows)*3)
-
-	i := 0
-	for _, c := range cols {
-		// A1 B1 C1 D1 E1 F1 G1 H1 I1...
-		res[i] = cross(rows, string(c))
-		i++
+// ParseGrid convert a grid to a board of possible values, or
+// return ErrNoSolution if a contradiction is detected while propagating the clues.
+func parseGrid(grid string, o *options, p *propagator) (board, error) {
+	g := p.g
+	gr, err := clues(grid, o, p.r)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, r := range rows {
-		res[i] = cross(string(r), cols)
-		i++
+	values := make(board, len(g.squares))
+	for s := range values {
+		values[s] = g.allDigits
 	}
 
-	rs := []string{`ABC`, `DEF`, `GHI`}
-	cs := []string{`123`, `456`, `789`}
-	for _, r := range rs {
-		for _, c := range cs {
-			res[i] = cross((r), string(c))
-			i++
+	// Let the constraints prune the candidates before the clues are placed
+	for _, c := range p.r.constraints {
+		if !c.Propagate(&Board{p: p, values: values}, -1) {
+			return nil, ErrNoSolution
 		}
 	}
 
-	return res
+	for s, v := range gr {
+		if v > 0 && !p.assign(values, s, 1<<uint(v-1)) {
+			return nil, ErrNoSolution
+		}
+	}
+	return values, nil
 }
 
-// CreateUnits find the units of each squares
-func createUnits(squares []string, unitList [][]string) map[string][][]string {
-	units := make(map[string][][]string, len(squares))
-
-	for _, s := range squares {
-		unit := make([][]string, 3)
-		i := 0
-		for _, u := range unitList {
-			// For each squares of the unit
-			for _, su := range u {
-				if s == su {
-					unit[i] = u
-					i++
-					break
-				}
+// Clues convert a grid to the values of its squares, 0 being an empty square. Return one of the grid errors if
+// the grid does not satisfy the validation policy or if a clue repeats a digit given in one of its peers.
+func clues(grid string, o *options, r *rules) ([]int, error) {
+	g := r.g
+	gr, err := g.gridValues(grid, o.policy())
+	if err != nil {
+		return nil, err
+	}
+
+	// Report the clues repeating a digit given in one of their peers
+	for s, v := range gr {
+		for _, s2 := range r.peers[s] {
+			if v > 0 && s2 < s && gr[s2] == v {
+				return nil, &ConflictError{Cells: []string{g.squares[s2], g.squares[s]}, Digit: g.digit(1 << uint(v-1))}
 			}
 		}
-		units[s] = unit
 	}
+	return gr, nil
+}
 
-	return units
+// Propagator runs the constraint propagation on boards, counts the work done and notifies the observer.
+// It is not safe for concurrent use, each search worker has its own.
+type propagator struct {
+	g        *Geometry
+	r        *rules
+	stats    Stats
+	observer Observer
 }
 
-// CreatePeers find the 20 peers of a square
-func createPeers(units map[string][][]string) map[string]map[string]bool {
-	peers := make(map[string]map[string]bool, len(units))
-
-	for s, ul := range units {
-		peer := make(map[string]bool, 20)
-		for _, u := range ul {
-			for _, su := range u {
-				if s != su {
-					peer[su] = true
-				}
-			}
-		}
-		peers[s] = peer
+// newPropagator create a propagator for the geometry, the variant, the constraints and the observer of the
+// solving options. Return an error if the variant is unknown or one of the constraints is not valid for the geometry.
+func newPropagator(o *options) (*propagator, error) {
+	cs, err := o.variant.Constraints()
+	if err != nil {
+		return nil, err
 	}
 
-	return peers
+	r, err := newRules(o.geometry, append(cs, o.constraints...))
+	if err != nil {
+		return nil, err
+	}
+	return &propagator{g: r.g, r: r, observer: o.observer}, nil
 }
 
-// GridValues match all the sudoku values to its square
-func gridValues(grid string) (map[string]string, error) {
-	values := make(map[string]string, len(grid))
-	chars := make([]string, len(grid))
-
-	// The number of clues given in the grid
-	nbClues := 0
-	var diffDigits []string
+// Eliminate removes the digit d (as a bit) from values[s]; propagate when values or places <= 2.
+// Return false if a contradiction is detected.
+func (p *propagator) eliminate(values board, s int, d uint32, reason Reason) bool {
+	// The value is already eliminated
+	if values[s]&d == 0 {
+		return true
+	}
 
-	// For each square
-	for i := 0; i < len(grid); i++ {
-		// Value of the square
-		str := grid[i : i+1]
+	p.stats.Eliminations++
+	if p.observer != nil {
+		p.observer.OnEliminate(p.g.squares[s], p.g.digit(d), reason)
+	}
 
-		// Valid that the square value is a digit from 1 to 9 ('0' or '.' for empties)
-		// and add it to the sudoku list of values.
-		if strings.Contains(digits, str) || strings.Contains("0.", str) {
-			chars[i] = str
-		}
-		if strings.Contains(digits, str) {
-			nbClues++
-			if !contains(diffDigits, str) {
-				diffDigits = append(diffDigits, str)
+	// Remove the value (d) from the square possible values
+	values[s] &^= d
+
+	// If a square (s) is reduced to one value (d2), then eliminate the value from the peers.
+	switch bits.OnesCount32(values[s]) {
+	case 0:
+		return false
+	case 1:
+		d2 := values[s]
+		for _, s2 := range p.r.peers[s] {
+			if !p.eliminate(values, s2, d2, ByPeer) {
+				return false
 			}
 		}
 	}
 
-	if len(chars) != 81 {
+	// If a unit (u) has only one possible place for a value (d), then put it there.
+	for _, u := range p.r.units[s] {
+		n, place := 0, 0
+		for _, s2 := range p.r.unitlist[u] {
+			if values[s2]&d != 0 {
+				n++
+				place = s2
+			}
+		}
 
-		return nil, fmt.Errorf("Invalid grid size: expected grid size of 81 found grid size of %d", len(chars))
-	} else if nbClues < 17 {
-		return nil, fmt.Errorf("Invalid number of squares filled: expected a minimum of 17 clues found %d", nbClues)
-	} else if len(diffDigits) < 8 {
-		return nil, fmt.Errorf("Invalid number of different clues digits: expected a minimum of 8 different digits found %d", len(diffDigits))
+		if n == 0 {
+			return false
+		} else if n == 1 && !p.assign(values, place, d) {
+			return false
+		}
 	}
 
-	// Map the square value to it's corresponding key (A1, B5, D8,...)
-	for i := 0; i < len(grid); i++ {
-		values[squares[i]] = chars[i]
+	// Let the constraints watching the square prune the candidates
+	for _, c := range p.r.watchers[s] {
+		if !p.r.constraints[c].Propagate(&Board{p: p, values: values}, s) {
+			return false
+		}
 	}
 
-	return values, nil
+	return true
 }
 
-// ParseGrid convert a grid to a dict of possible values, {square: digits}, or
-// return nil if a contradiction is detected.
-func parseGrid(grid string) (values map[string]string, err error) {
-	values = make(map[string]string, len(squares))
-	for _, s := range squares {
-		values[s] = digits
+// Assign eliminate all the other values (except d) from a square possible values and propagate.
+// Return false if a contradiction is detected.
+func (p *propagator) assign(values board, s int, d uint32) bool {
+	p.stats.Assigns++
+	if p.observer != nil {
+		p.observer.OnAssign(p.g.squares[s], p.g.digit(d))
 	}
 
-	gr, err := gridValues(grid)
-	for s, v := range gr {
-		if strings.Contains(digits, v) {
-			values = assign(values, s, v)
-			if values == nil {
-				return nil, nil
-			}
+	otherValues := values[s] &^ d
+	for otherValues != 0 {
+		v := otherValues & -otherValues
+		otherValues &^= v
+		if !p.eliminate(values, s, v, ByAssignment) {
+			return false
 		}
 	}
-	return values, err
+	return true
 }
 
-// Eliminate removes d from values[s]; propagate when values or places <= 2.
-// Return values, except return False if a contradiction is detected.
-func eliminate(values map[string]string, s string, v string) (map[string]string, bool) {
-	// The value is already eliminated
-	if !strings.Contains(values[s], v) {
-		return values, true
-	}
-
-	// Remove all occurrences of the value (v) from the square possible values
-	values[s] = strings.Replace(values[s], v, "", -1)
+// Solve the sudoku in input
+func Solve(grid string) (map[string]string, error) {
+	return SolveContext(context.Background(), grid)
+}
 
-	// If a square (s) is reduced to one value (v2), then eliminate the value from the peers.
-	if len(values[s]) == 0 {
-		return nil, false
-	} else if len(values[s]) == 1 {
-		v2 := values[s]
+// SolveContext solve the sudoku in input until ctx is done.
+// The search runs on the number of workers set with WithWorkers, with WithDeterministic the lexicographically
+// first solution is always returned when the sudoku has several. If ctx is done before a solution is found,
+// ctx.Err() is returned. If the search budget set with WithMaxNodes is spent, the candidates of the grid
+// after propagation are returned with ErrBudgetExceeded.
+func SolveContext(ctx context.Context, grid string, opts ...Option) (map[string]string, error) {
+	values, _, err := SolveStats(ctx, grid, opts...)
+	return values, err
+}
 
-		for s2 := range peers[s] {
-			if _, ok := eliminate(values, s2, v2); !ok {
-				return nil, false
-			}
-		}
+// SolveStats solve the sudoku in input like SolveContext and also return the effort spent on the search.
+// The stats are nil only if the grid could not be parsed.
+// The errors returned are either ctx.Err(), ErrNoSolution, ErrBudgetExceeded, one of the grid errors:
+// *GridSizeError, *InvalidCharacterError, *TooFewCluesError and *ConflictError, an *OutsideClueError for a grid
+// followed by clues which were not read with ParsePuzzle, an *UnknownVariantError
+// or the error of an invalid constraint.
+func SolveStats(ctx context.Context, grid string, opts ...Option) (map[string]string, *Stats, error) {
+	start := time.Now()
+	o := newOptions(opts)
+
+	p, err := newPropagator(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	pg, err := parseGrid(grid, o, p)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// If a unit (u) has only one possible place for a value (v), then put it there.
-	for _, u := range units[s] {
-		dplaces := []string{}
-		for _, s := range u {
-			if strings.Contains(values[s], v) {
-				dplaces = append(dplaces, s)
-			}
-		}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		if len(dplaces) == 0 {
-			return nil, false
-		} else if len(dplaces) == 1 {
-			if assign(values, dplaces[0], v) == nil {
-				return nil, false
-			}
-		}
-	}
+	sr := newSearcher(o, p.r, cancel)
+	res := sr.search(ctx, pg)
 
-	return values, true
-}
+	stats := sr.stats
+	stats.add(p.stats)
+	stats.Duration = time.Since(start)
 
-// Assign eliminate all the other values (except v) from a square possible values and propagate.
-func assign(values map[string]string, s string, v string) map[string]string {
-	otherValues := strings.Replace(values[s], v, "", -1)
-	for _, v := range otherValues {
-		if _, ok := eliminate(values, s, string(v)); !ok {
-			return nil
-		}
+	switch {
+	case res != nil:
+		return o.geometry.toMap(res), &stats, nil
+	case atomic.LoadInt32(&sr.exceeded) == 1:
+		return o.geometry.toMap(pg), &stats, ErrBudgetExceeded
+	case ctx.Err() != nil:
+		return nil, &stats, ctx.Err()
 	}
-	return values
+	return nil, &stats, ErrNoSolution
 }
 
-// Search using depth-first search and propagation, try all possible values.
-func search(values map[string]string) (map[string]string, error) {
-	if values == nil {
-		return nil, errors.New("The sudoku contains errors and can not be solved")
-	}
+// Solutions stream every solution of the sudoku in input on the returned channel.
+// The channel is closed once every solution has been sent, cancel ctx to stop the enumeration early.
+// The options describing the grid apply, the ones tuning the search (workers, budget) are ignored.
+func Solutions(ctx context.Context, grid string, opts ...Option) (<-chan map[string]string, error) {
+	o := newOptions(opts)
 
-	// Check if there is only one remaining possibility in every square
-	// If true, return the solved sudoku
-	solved := true
-	for s := range values {
-		if len(values[s]) != 1 {
-			solved = false
-		}
-	}
-
-	if solved {
-		return values, nil
+	p, err := newPropagator(o)
+	if err != nil {
+		return nil, err
 	}
-
-	// Chose the first unfilled square with the fewest possibilities
-	min := len(digits) + 1
-	sq := ""
-	for _, s := range squares {
-		l := len(values[s])
-		if l > 1 && l < min {
-			sq = s
-			min = l
-		}
+	pg, err := parseGrid(grid, o, p)
+	if err == ErrNoSolution {
+		ch := make(chan map[string]string)
+		close(ch)
+		return ch, nil
+	} else if err != nil {
+		return nil, err
 	}
 
 	ch := make(chan map[string]string)
-	for _, v := range values[sq] {
-		go func(val string) {
-			newValues := cloneValues(values)
-			value, _ := search(assign(newValues, sq, val))
-			if value != nil {
-				ch <- value
-			}
-		}(string(v))
-	}
-	return <-ch, nil
+	go func() {
+		defer close(ch)
+		enumerate(ctx, p, pg, ch)
+	}()
+
+	return ch, nil
 }
 
-// CloneValues from one map to another
-func cloneValues(m map[string]string) map[string]string {
-	newMap := make(map[string]string, len(m))
-	for k, v := range m {
-		newMap[k] = v
-	}
-	return newMap
+// CountSolutions return the number of solutions of the sudoku in input.
+// The search stops as soon as limit solutions are found, a limit of 0 or less counts them all.
+func CountSolutions(grid string, limit int, opts ...Option) (int, error) {
+	return CountSolutionsContext(context.Background(), grid, limit, opts...)
 }
 
-// Solve the sudoku in input
-func Solve(grid string) (map[string]string, error) {
-	pg, err := parseGrid(grid)
+// CountSolutionsContext count the solutions of the sudoku in input like CountSolutions until ctx is done.
+// The options describing the grid and the budget set with WithMaxNodes apply, the workers are ignored.
+// If ctx is done or the budget is spent before the count is over, the solutions found so far are returned
+// along with ctx.Err() or ErrBudgetExceeded.
+func CountSolutionsContext(ctx context.Context, grid string, limit int, opts ...Option) (int, error) {
+	o := newOptions(opts)
+
+	p, err := newPropagator(o)
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	pg, err := parseGrid(grid, o, p)
+	if err == ErrNoSolution {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
 	}
 
-	return search(pg)
+	c := &counter{ctx: ctx, p: p, maxNodes: o.maxNodes}
+	n := c.count(pg, limit)
+	return n, c.err
 }
 
-// Display the solved sudoku
-func Display(values map[string]string) {
-	for i, row := range rows {
-		for j, col := range digits {
-			if j == 3 || j == 6 {
-				fmt.Printf("| ")
-			}
-			fmt.Printf("%v ", values[string(row)+string(col)])
-		}
-		fmt.Println()
-		if i == 2 || i == 5 {
-			fmt.Println("------+-------+-------")
-		}
-	}
+// IsUnique report whether the sudoku in input has exactly one solution
+func IsUnique(grid string, opts ...Option) (bool, error) {
+	return IsUniqueContext(context.Background(), grid, opts...)
 }
 
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
-		}
-	}
-	return false
+// IsUniqueContext report whether the sudoku in input has exactly one solution like IsUnique until ctx is done.
+// It returns false with ctx.Err() or ErrBudgetExceeded if the answer is not known when the search is stopped.
+func IsUniqueContext(ctx context.Context, grid string, opts ...Option) (bool, error) {
+	n, err := CountSolutionsContext(ctx, grid, 2, opts...)
+	return n == 1 && err == nil, err
+}
+
+// Display the solved sudoku, a classic 9x9 one. Use the Display method of the geometry for other sudokus.
+func Display(values map[string]string) {
+	Classic.Display(values)
 }
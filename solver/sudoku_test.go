@@ -1,8 +1,11 @@
 package solver_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -21,6 +24,10 @@ const emptyGrid = ""
 const wrongGrid = "..757..3.1....a.2.7...234......8x..4..7..4...49....6.5.42...3e....7..9....18....."
 const shortCluesGrid = "4.....8.5............7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......"
 const invalidNbDiffDigitsGrid = "4.....8.5.3..........7......2.....6.....8.4.........6....6.3.7.5..2......64......"
+const easyGrid = "..3.2.6..9..3.5..1..18.64....81.29..7.......8..67.82....26.95..8..2.3..9..5.1.3.."
+const noSolutionGrid = "49....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......"
+const twoSolutionsGrid = "..7..9825..2..8947958724316825437169791586432346912758289643571573291684164875293"
+const manySolutionsGrid = "417369825632158947958724316......................................................"
 
 func TestSudokuSolving(t *testing.T) {
 	Convey("Given sudokus grid and a solver", t, func() {
@@ -37,7 +44,10 @@ func TestSudokuSolving(t *testing.T) {
 		Convey("When Solve is called from the solver with a short grid", func() {
 			_, err := solver.Solve(errorGrid)
 
-			Convey("Then return an error", func() {
+			Convey("Then return a GridSizeError", func() {
+				var sizeErr *solver.GridSizeError
+				So(errors.As(err, &sizeErr), ShouldBeTrue)
+				So(sizeErr.Size, ShouldEqual, len(errorGrid))
 				So(err.Error(), ShouldEqual, fmt.Sprintf("Invalid grid size: expected grid size of 81 found grid size of %d", len(errorGrid)))
 			})
 		})
@@ -53,23 +63,41 @@ func TestSudokuSolving(t *testing.T) {
 		Convey("When Solve is called from the solver with an invalid grid", func() {
 			_, err := solver.Solve(invalidGrid)
 
-			Convey("Then return an error", func() {
-				So(err.Error(), ShouldEqual, "The sudoku contains errors and can not be solved")
+			Convey("Then return a ConflictError with the conflicting cells", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+				So(conflictErr.Cells, ShouldResemble, []string{"A3", "A5"})
+				So(conflictErr.Digit, ShouldEqual, "7")
+				So(err.Error(), ShouldEqual, "The sudoku contains errors and can not be solved: digit 7 is given in A3 and A5")
+			})
+		})
+
+		Convey("When Solve is called from the solver with a grid having no solution", func() {
+			_, err := solver.Solve(noSolutionGrid)
+
+			Convey("Then return ErrNoSolution", func() {
+				So(err, ShouldEqual, solver.ErrNoSolution)
 			})
 		})
 
 		Convey("When Solve is called from the solver with a grid containing wrong character", func() {
 			_, err := solver.Solve(wrongGrid)
 
-			Convey("Then return an error", func() {
-				So(err.Error(), ShouldEqual, "The sudoku contains errors and can not be solved")
+			Convey("Then return an InvalidCharacterError with the position of the character", func() {
+				var charErr *solver.InvalidCharacterError
+				So(errors.As(err, &charErr), ShouldBeTrue)
+				So(charErr.Index, ShouldEqual, 14)
+				So(charErr.Char, ShouldEqual, "a")
 			})
 		})
 
 		Convey("When Solve is called from the solver with a grid containing not enough clues", func() {
 			_, err := solver.Solve(shortCluesGrid)
 
-			Convey("Then return an error", func() {
+			Convey("Then return a TooFewCluesError", func() {
+				var cluesErr *solver.TooFewCluesError
+				So(errors.As(err, &cluesErr), ShouldBeTrue)
+				So(cluesErr.Clues, ShouldEqual, 16)
 				So(err.Error(), ShouldEqual, "Invalid number of squares filled: expected a minimum of 17 clues found 16")
 			})
 		})
@@ -96,6 +124,347 @@ func TestSudokuSolving(t *testing.T) {
 	})
 }
 
+func TestSudokuSolvingContext(t *testing.T) {
+	Convey("Given sudokus grid and a solver", t, func() {
+		Convey("When SolveContext is called with a cancelled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := solver.SolveContext(ctx, grid)
+
+			Convey("Then return the context error", func() {
+				So(err, ShouldEqual, context.Canceled)
+			})
+		})
+
+		Convey("When SolveContext is called with an expired deadline", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+			defer cancel()
+			time.Sleep(time.Millisecond)
+			_, err := solver.SolveContext(ctx, grid)
+
+			Convey("Then return the context error", func() {
+				So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveContext is called with a budget too small to solve the grid", func() {
+			values, err := solver.SolveContext(context.Background(), grid, solver.WithMaxNodes(1))
+
+			Convey("Then return the partial candidates with ErrBudgetExceeded", func() {
+				So(err, ShouldEqual, solver.ErrBudgetExceeded)
+				So(len(values), ShouldEqual, 81)
+				So(len(values["A2"]), ShouldBeGreaterThan, 1)
+			})
+		})
+
+		Convey("When SolveContext is called with a budget large enough to solve the grid", func() {
+			_, err := solver.SolveContext(context.Background(), grid, solver.WithMaxNodes(100000))
+
+			Convey("Then show a solved sudoku", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestSudokuSolvingScheduler(t *testing.T) {
+	Convey("Given sudokus grid and a solver", t, func() {
+		Convey("When SolveContext is called with a single worker", func() {
+			values, err := solver.SolveContext(context.Background(), grid, solver.WithWorkers(1))
+
+			Convey("Then show a solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(toString(values), ShouldEqual, "417369825632158947958724316825437169791586432346912758289643571573291684164875293")
+			})
+		})
+
+		Convey("When SolveContext is called in deterministic mode with a grid having two solutions", func() {
+			ch, err := solver.Solutions(context.Background(), twoSolutionsGrid)
+			So(err, ShouldBeNil)
+
+			first := ""
+			for s := range ch {
+				if str := toString(s); first == "" || str < first {
+					first = str
+				}
+			}
+
+			values, err := solver.SolveContext(context.Background(), twoSolutionsGrid, solver.WithDeterministic())
+
+			Convey("Then return the lexicographically first solution", func() {
+				So(err, ShouldBeNil)
+				So(toString(values), ShouldEqual, first)
+			})
+		})
+
+		Convey("When SolveContext is called in deterministic mode with different numbers of workers", func() {
+			expected, err := solver.SolveContext(context.Background(), manySolutionsGrid, solver.WithDeterministic(), solver.WithWorkers(1))
+			So(err, ShouldBeNil)
+
+			same := true
+			for _, w := range []int{2, 4, 16, 16, 16} {
+				values, err := solver.SolveContext(context.Background(), manySolutionsGrid, solver.WithDeterministic(), solver.WithWorkers(w))
+				same = same && err == nil && toString(values) == toString(expected)
+			}
+
+			Convey("Then always return the same solution", func() {
+				So(same, ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveContext is called in deterministic mode with the hardest grids\n", func() {
+			solved := 0
+			grids := fromFile("./_tests/hardest.txt")
+			for _, g := range grids {
+				if _, err := solver.SolveContext(context.Background(), g, solver.WithDeterministic()); err == nil {
+					solved++
+				}
+			}
+
+			Convey("Then be all solved", func() {
+				So(solved, ShouldEqual, len(grids))
+			})
+		})
+	})
+}
+
+func TestSudokuSolvingStats(t *testing.T) {
+	Convey("Given sudokus grid and a solver", t, func() {
+		Convey("When SolveStats is called with a grid solved by propagation alone", func() {
+			_, stats, err := solver.SolveStats(context.Background(), easyGrid)
+
+			Convey("Then no guess is made", func() {
+				So(err, ShouldBeNil)
+				So(stats.Nodes, ShouldEqual, 1)
+				So(stats.Guesses, ShouldEqual, 0)
+				So(stats.Backtracks, ShouldEqual, 0)
+				So(stats.MaxDepth, ShouldEqual, 0)
+				So(stats.Assigns, ShouldBeGreaterThan, 0)
+				So(stats.Eliminations, ShouldBeGreaterThan, 0)
+				So(stats.Duration, ShouldBeGreaterThan, 0)
+			})
+		})
+
+		Convey("When SolveStats is called with a grid needing a search", func() {
+			_, stats, err := solver.SolveStats(context.Background(), grid, solver.WithWorkers(1))
+
+			Convey("Then the search effort is counted", func() {
+				So(err, ShouldBeNil)
+				So(stats.Nodes, ShouldBeGreaterThan, 1)
+				So(stats.Guesses, ShouldBeGreaterThan, 0)
+				So(stats.Backtracks, ShouldBeLessThan, stats.Guesses)
+				So(stats.MaxDepth, ShouldBeGreaterThan, 0)
+			})
+		})
+
+		Convey("When SolveStats is called with a short grid", func() {
+			_, stats, err := solver.SolveStats(context.Background(), errorGrid)
+
+			Convey("Then no stats are returned", func() {
+				So(err, ShouldNotBeNil)
+				So(stats, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestGridValidationPolicy(t *testing.T) {
+	Convey("Given sparse grids and a solver", t, func() {
+		blankGrid := strings.Repeat(".", 81)
+
+		Convey("When Solve is called with an empty grid and the default policy", func() {
+			_, err := solver.Solve(blankGrid)
+
+			Convey("Then return a TooFewCluesError", func() {
+				var cluesErr *solver.TooFewCluesError
+				So(errors.As(err, &cluesErr), ShouldBeTrue)
+				So(cluesErr.MinClues, ShouldEqual, 17)
+			})
+		})
+
+		Convey("When SolveContext is called with an empty grid and the lenient policy", func() {
+			values, err := solver.SolveContext(context.Background(), blankGrid, solver.WithValidation(solver.LenientValidation))
+
+			Convey("Then show a solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(len(toString(values)), ShouldEqual, 81)
+			})
+		})
+
+		Convey("When CountSolutions is called with an empty grid and the lenient policy", func() {
+			n, err := solver.CountSolutions(blankGrid, 5, solver.WithValidation(solver.LenientValidation))
+
+			Convey("Then stop counting at the limit", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 5)
+			})
+		})
+
+		Convey("When CountSolutions is called with a grid having fewer clues than a custom minimum", func() {
+			policy := solver.ValidationPolicy{MinClues: 20, MinDigits: 8, AllowEmpty: true}
+			_, err := solver.CountSolutions(grid, 1, solver.WithValidation(policy))
+			_, errEmpty := solver.CountSolutions(blankGrid, 1, solver.WithValidation(policy))
+
+			Convey("Then only the sparse grid is rejected", func() {
+				var cluesErr *solver.TooFewCluesError
+				So(errors.As(err, &cluesErr), ShouldBeTrue)
+				So(err.Error(), ShouldEqual, "Invalid number of squares filled: expected a minimum of 20 clues found 17")
+				So(errEmpty, ShouldBeNil)
+			})
+		})
+
+		Convey("When IsUnique is called with a grid having fewer clues than the default minimum and a custom policy", func() {
+			unique, err := solver.IsUnique(shortCluesGrid, solver.WithValidation(solver.ValidationPolicy{MinClues: 16}))
+
+			Convey("Then the grid is checked", func() {
+				So(err, ShouldBeNil)
+				So(unique, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestSolutionCounting(t *testing.T) {
+	Convey("Given sudokus grid and a solver", t, func() {
+		Convey("When CountSolutions is called with a grid having a single solution", func() {
+			n, err := solver.CountSolutions(grid, 0)
+
+			Convey("Then return one solution", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When CountSolutions is called with a grid having two solutions", func() {
+			n, err := solver.CountSolutions(twoSolutionsGrid, 0)
+
+			Convey("Then return two solutions", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 2)
+			})
+		})
+
+		Convey("When CountSolutions is called with a limit lower than the number of solutions", func() {
+			n, err := solver.CountSolutions(manySolutionsGrid, 10)
+
+			Convey("Then stop counting at the limit", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 10)
+			})
+		})
+
+		Convey("When CountSolutionsContext is called on a grid having many solutions with a cancelled context or a node budget", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, errCancelled := solver.CountSolutionsContext(ctx, manySolutionsGrid, 0)
+			n, errBudget := solver.CountSolutionsContext(context.Background(), manySolutionsGrid, 0, solver.WithMaxNodes(1000))
+			unique, errUnique := solver.IsUniqueContext(ctx, grid)
+
+			Convey("Then stop counting with the context error or ErrBudgetExceeded", func() {
+				So(errCancelled, ShouldEqual, context.Canceled)
+				So(errBudget, ShouldEqual, solver.ErrBudgetExceeded)
+				So(n, ShouldBeGreaterThan, 0)
+				So(unique, ShouldBeFalse)
+				So(errUnique, ShouldEqual, context.Canceled)
+			})
+		})
+
+		Convey("When CountSolutions is called with a grid having no solution", func() {
+			n, err := solver.CountSolutions(noSolutionGrid, 0)
+
+			Convey("Then return no solution", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When CountSolutions is called with an invalid grid", func() {
+			_, err := solver.CountSolutions(invalidGrid, 0)
+
+			Convey("Then return a ConflictError", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+			})
+		})
+
+		Convey("When IsUnique is called with grids having one and two solutions", func() {
+			unique, err := solver.IsUnique(grid)
+			notUnique, err2 := solver.IsUnique(twoSolutionsGrid)
+
+			Convey("Then only the first one is unique", func() {
+				So(err, ShouldBeNil)
+				So(err2, ShouldBeNil)
+				So(unique, ShouldBeTrue)
+				So(notUnique, ShouldBeFalse)
+			})
+		})
+
+		Convey("When IsUnique is called with a short grid", func() {
+			_, err := solver.IsUnique(errorGrid)
+
+			Convey("Then return an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestSolutionsEnumeration(t *testing.T) {
+	Convey("Given sudokus grid and a solver", t, func() {
+		Convey("When Solutions is called with a grid having two solutions", func() {
+			ch, err := solver.Solutions(context.Background(), twoSolutionsGrid)
+			So(err, ShouldBeNil)
+
+			solutions := []map[string]string{}
+			for s := range ch {
+				solutions = append(solutions, s)
+			}
+
+			Convey("Then both solutions are sent before the channel is closed", func() {
+				So(len(solutions), ShouldEqual, 2)
+				So(solutions[0], ShouldNotResemble, solutions[1])
+			})
+		})
+
+		Convey("When Solutions is cancelled after a few solutions of an under-constrained grid", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ch, err := solver.Solutions(ctx, manySolutionsGrid)
+			So(err, ShouldBeNil)
+
+			n := 0
+			for range ch {
+				n++
+				if n == 3 {
+					cancel()
+				}
+			}
+
+			Convey("Then the channel is closed", func() {
+				So(n, ShouldBeBetweenOrEqual, 3, 4)
+			})
+		})
+
+		Convey("When Solutions is called with an invalid grid", func() {
+			_, err := solver.Solutions(context.Background(), invalidGrid)
+
+			Convey("Then return a ConflictError", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func BenchmarkSolve(b *testing.B) {
+	grids := fromFile("./_tests/top95.txt")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		solver.Solve(grids[i%len(grids)])
+	}
+}
+
 // compareSlices compare values of two 3D arrays.
 // Return true if they are the same
 func compareSlices3D(A, B [][]string) bool {
@@ -143,16 +512,30 @@ func compareSlices2D(A map[string]bool, B []string) bool {
 	return true
 }
 
-// timeSolve calculate the time it takes to solve a sudoku
-func timeSolve(grid string) (int64, bool) {
+// toString convert a solved sudoku to a string of values ordered by square
+func toString(values map[string]string) (res string) {
+	keys := []string{}
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		res += values[k]
+	}
+	return res
+}
+
+// timeSolve calculate the time and the search effort it takes to solve a sudoku
+func timeSolve(grid string) (int64, *solver.Stats, bool) {
 	nanosStart := time.Now().UnixNano()
 
 	// Solve the sudoku in input
-	_, err := solver.Solve(grid)
+	_, stats, err := solver.SolveStats(context.Background(), grid)
 
 	duration := time.Now().UnixNano() - nanosStart
 
-	return duration, err == nil
+	return duration, stats, err == nil
 }
 
 // fromFile load a sudoku from a file
@@ -170,11 +553,17 @@ func nanoconv(nanos int64) float64 {
 // solveAll the sudoku inside a file
 func solveAll(grids []string, name string, t *testing.T) {
 	times := make([]int64, len(grids))
+	nodes := make([]int64, len(grids))
+	guesses := make([]int64, len(grids))
 	results := make([]bool, len(grids))
 
 	for i, grid := range grids {
-		t, result := timeSolve(grid)
+		t, stats, result := timeSolve(grid)
 		times[i] = t
+		if stats != nil {
+			nodes[i] = stats.Nodes
+			guesses[i] = stats.Guesses
+		}
 		results[i] = result
 	}
 
@@ -189,6 +578,12 @@ func solveAll(grids []string, name string, t *testing.T) {
 			nanoconv(sum(times))/float64(n), // Average time to solve this type of sudoku
 			float64(n)/nanoconv(sum(times)), // Average hertz used to solve this type of sudoku
 			nanoconv(max(times)))            // The maximum time it took to solve one of the sudoku
+
+		// E.g. Searched avg 1.0 nodes and 0.0 guesses per puzzle, max 1 nodes.
+		fmt.Printf("Searched avg %.1f nodes and %.1f guesses per puzzle, max %d nodes.\n",
+			float64(sum(nodes))/float64(n),   // Average number of search nodes explored
+			float64(sum(guesses))/float64(n), // Average number of values guessed
+			max(nodes))                       // The maximum number of nodes explored for one of the sudoku
 	}
 
 	Convey("Then be all solved", func() {
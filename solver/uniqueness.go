@@ -0,0 +1,361 @@
+package solver
+
+import "math/bits"
+
+// uniquenessTechniques holds the techniques assuming the sudoku has a single solution, used with WithUniqueness only
+var uniquenessTechniques = map[Technique]bool{
+	UniqueRectangle1:    true,
+	UniqueRectangle2:    true,
+	UniqueRectangle3:    true,
+	UniqueRectangle4:    true,
+	UniqueRectangle5:    true,
+	UniqueRectangle6:    true,
+	AvoidableRectangle1: true,
+	AvoidableRectangle2: true,
+	BUGPlusOne:          true,
+}
+
+// rectangleSides are the pairs of corners of a rectangle sharing a side: the corners are numbered in the order a
+// square, the square on its row, the square on its column and the opposite square
+var rectangleSides = [][2]int{{0, 1}, {2, 3}, {0, 2}, {1, 3}}
+
+// side report whether corners i and j of a rectangle share a side
+func side(i, j int) bool {
+	for _, sd := range rectangleSides {
+		if sd == [2]int{i, j} || sd == [2]int{j, i} {
+			return true
+		}
+	}
+	return false
+}
+
+// Rectangles call f with the rectangles of four squares whose digits can be swapped between two of their corners
+// sharing a side without breaking a unit: every unit holds none of the corners or two sharing a side, which keeps
+// the rectangles spanning two boxes. Return the first step f returns.
+func (l *logic) rectangles(f func(sq [4]int) *Step) *Step {
+	if l.rects == nil {
+		l.rects = [][4]int{}
+		for s1 := range l.values {
+			r1, c1 := l.g.position(s1)
+			for c2 := c1 + 1; c2 < l.g.cols; c2++ {
+				for r3 := r1 + 1; r3 < l.g.rows; r3++ {
+					sq := [4]int{s1, l.g.at(r1, c2), l.g.at(r3, c1), l.g.at(r3, c2)}
+					if sq[1] >= 0 && sq[2] >= 0 && sq[3] >= 0 && l.swappable(sq) {
+						l.rects = append(l.rects, sq)
+					}
+				}
+			}
+		}
+	}
+
+	for _, sq := range l.rects {
+		if st := f(sq); st != nil {
+			return st
+		}
+	}
+	return nil
+}
+
+// Swappable report whether every unit holds none of the corners of the rectangle or two sharing a side
+func (l *logic) swappable(sq [4]int) bool {
+	for _, s := range sq {
+		for _, u := range l.r.units[s] {
+			var in []int
+			for i, c := range sq {
+				if contains(l.r.unitlist[u], c) {
+					in = append(in, i)
+				}
+			}
+			if len(in) != 2 || !side(in[0], in[1]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// UniqueRectangle finds the four squares of a rectangle holding the same two digits ab, which would let the two
+// digits be swapped if they were all left with ab only: a sudoku having a single solution, one of the squares
+// holds another digit. The types tell how it is known:
+//
+//  1. three squares only hold ab, the fourth can not hold them
+//  2. two squares on a side only hold ab, the two others ab and the same c: c is in one of them
+//  3. two squares on a side only hold ab, the two others hold extra digits making a subset with other squares
+//     of a unit
+//  4. two squares on a side only hold ab, and a of the unit of the two others can only go in them: b can not
+//  5. two or three squares hold ab and the same c, the others only ab: c is in one of them
+//  6. two opposite squares only hold ab, and a can only go in the rectangle in two lines: it is in these squares
+func (l *logic) uniqueRectangle(t int) *Step {
+	return l.rectangles(func(sq [4]int) *Step {
+		ab := l.g.allDigits
+		for _, s := range sq {
+			if l.placed[s] {
+				return nil
+			}
+			ab &= l.values[s]
+		}
+		if bits.OnesCount32(ab) != 2 {
+			return nil
+		}
+
+		// The floor squares only hold ab, the roof ones hold other digits too
+		var floor, roof []int
+		for i, s := range sq {
+			if l.values[s] == ab {
+				floor = append(floor, i)
+			} else {
+				roof = append(roof, sq[i])
+			}
+		}
+		if len(roof) == 0 {
+			// The deadly pattern itself: the sudoku has several solutions
+			l.deadly = true
+			return nil
+		}
+
+		switch t {
+		case 1:
+			if len(roof) == 1 {
+				st := l.begin(nil, sq[:], ab)
+				l.eliminate(roof[0], ab)
+				return st
+			}
+		case 2, 5:
+			if len(roof) < 2 {
+				return nil
+			}
+			c := l.values[roof[0]] &^ ab
+			for _, s := range roof {
+				if l.values[s] != ab|c {
+					return nil
+				}
+			}
+			if bits.OnesCount32(c) != 1 || (t == 2) != (len(roof) == 2 && side(floor[0], floor[1])) {
+				return nil
+			}
+			return l.rectangleElimination(sq, ab, roof, c)
+		case 3:
+			if len(roof) == 2 && side(floor[0], floor[1]) {
+				return l.rectangleSubset(sq, ab, roof)
+			}
+		case 4:
+			if len(roof) != 2 || !side(floor[0], floor[1]) {
+				return nil
+			}
+			for _, u := range l.r.units[roof[0]] {
+				if !contains(l.r.unitlist[u], roof[1]) {
+					continue
+				}
+				for d := ab; d != 0; d &= d - 1 {
+					if l.only(u, d&-d, roof) {
+						st := l.begin([]int{u}, sq[:], ab)
+						for _, s := range roof {
+							l.eliminate(s, ab&^(d&-d))
+						}
+						return st
+					}
+				}
+			}
+		case 6:
+			if len(floor) != 2 || side(floor[0], floor[1]) {
+				return nil
+			}
+			for d := ab; d != 0; d &= d - 1 {
+				// The rows, then the columns
+				for _, sides := range [][2][2]int{{{0, 1}, {2, 3}}, {{0, 2}, {1, 3}}} {
+					u1 := l.lockedIn(d&-d, sq[sides[0][0]], sq[sides[0][1]])
+					u2 := l.lockedIn(d&-d, sq[sides[1][0]], sq[sides[1][1]])
+					if u1 < 0 || u2 < 0 {
+						continue
+					}
+					st := l.begin([]int{u1, u2}, sq[:], ab)
+					for _, s := range roof {
+						l.eliminate(s, d&-d)
+					}
+					return st
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// RectangleElimination eliminates digit c from the squares seeing all the squares of seen, recording a step
+// based on the rectangle. Return nil if c can not be eliminated anywhere.
+func (l *logic) rectangleElimination(sq [4]int, ab uint32, seen []int, c uint32) *Step {
+	var targets []int
+	for _, s := range l.r.peers[seen[0]] {
+		if !l.placed[s] && l.values[s]&c != 0 && !contains(sq[:], s) && l.seesAll(s, seen[1:]) {
+			targets = append(targets, s)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	st := l.begin(nil, sq[:], ab)
+	for _, s := range targets {
+		l.eliminate(s, c)
+	}
+	return st
+}
+
+// RectangleSubset finds a unit holding both roof squares of a rectangle, where their extra digits make a naked
+// subset with other squares: one of the roof squares holding an extra digit, the digits of the subset are
+// eliminated from the rest of the unit
+func (l *logic) rectangleSubset(sq [4]int, ab uint32, roof []int) *Step {
+	extra := (l.values[roof[0]] | l.values[roof[1]]) &^ ab
+	for _, u := range l.r.units[roof[0]] {
+		if !contains(l.r.unitlist[u], roof[1]) {
+			continue
+		}
+		var free []int
+		for _, s := range l.r.unitlist[u] {
+			if !l.placed[s] && !contains(roof, s) {
+				free = append(free, s)
+			}
+		}
+
+		for n := 1; n <= 3 && n < len(free); n++ {
+			var st *Step
+			subsets(len(free), n, func(picked []int) bool {
+				squares, digits := make([]int, n), extra
+				for i, p := range picked {
+					squares[i] = free[p]
+					digits |= l.values[free[p]]
+				}
+				if bits.OnesCount32(digits) != n+1 || !l.removable(free, squares, digits) {
+					return false
+				}
+
+				st = l.begin([]int{u}, append(sq[:], squares...), ab|digits)
+				for _, s := range free {
+					if !contains(squares, s) {
+						l.eliminate(s, digits)
+					}
+				}
+				return true
+			})
+			if st != nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// Only report whether digit d can only go in the squares within unit u
+func (l *logic) only(u int, d uint32, squares []int) bool {
+	for _, s := range l.r.unitlist[u] {
+		if l.values[s]&d != 0 && !contains(squares, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// LockedIn returns a unit holding squares s1 and s2 where digit d can only go in them, -1 if there is none
+func (l *logic) lockedIn(d uint32, s1, s2 int) int {
+	for _, u := range l.r.units[s1] {
+		if contains(l.r.unitlist[u], s2) && l.only(u, d, []int{s1, s2}) {
+			return u
+		}
+	}
+	return -1
+}
+
+// AvoidableRectangle finds a rectangle whose squares were placed by the solver, the clues being left out: the
+// digits of two opposite corners could be swapped with the other two if they were the same. The types tell how
+// it is known:
+//
+//  1. three squares hold a, b and b, the corner opposite to a can not hold a
+//  2. two squares on a side hold a and b, the two others b and c, and a and c: c is in one of them
+func (l *logic) avoidableRectangle(t int) *Step {
+	return l.rectangles(func(sq [4]int) *Step {
+		var placed, free []int
+		for i, s := range sq {
+			switch {
+			case l.given[s]:
+				return nil
+			case l.placed[s]:
+				placed = append(placed, i)
+			default:
+				free = append(free, i)
+			}
+		}
+
+		switch {
+		case t == 1 && len(free) == 1:
+			// The corner opposite to the free one holds a, the two others b
+			f := free[0]
+			a := l.values[sq[3-f]]
+			b := l.values[sq[f^1]]
+			if a == b || l.values[sq[f^2]] != b || l.values[sq[f]]&a == 0 {
+				return nil
+			}
+			st := l.begin(nil, sq[:], a|b)
+			l.eliminate(sq[f], a)
+			return st
+
+		case t == 2 && len(free) == 2 && side(placed[0], placed[1]):
+			// The free corner sharing a side with the corner holding a needs b, the other one a
+			i, j := placed[0], placed[1]
+			a, b := l.values[sq[i]], l.values[sq[j]]
+			fi, fj := free[0], free[1]
+			if !side(i, fi) {
+				fi, fj = fj, fi
+			}
+			c := l.values[sq[fi]] &^ b
+			if bits.OnesCount32(c) != 1 || l.values[sq[fi]] != b|c || l.values[sq[fj]] != a|c {
+				return nil
+			}
+			return l.rectangleElimination(sq, a|b, []int{sq[fi], sq[fj]}, c)
+		}
+		return nil
+	})
+}
+
+// BUGPlusOne finds a board whose free squares all hold two candidates but one holding three, every candidate
+// appearing twice in each unit but one digit of that square: without it the board would have two solutions,
+// so the digit is placed in the square
+func (l *logic) bugPlusOne() *Step {
+	plus := -1
+	for s, v := range l.values {
+		switch n := bits.OnesCount32(v); {
+		case l.placed[s] || n == 2:
+		case n == 3 && plus < 0:
+			plus = s
+		default:
+			return nil
+		}
+	}
+	if plus < 0 {
+		return nil
+	}
+
+	var digit uint32
+	for u, unit := range l.r.unitlist {
+		for d := uint32(1); d&l.g.allDigits != 0; d <<= 1 {
+			n := 0
+			for _, s := range unit {
+				if !l.placed[s] && l.values[s]&d != 0 {
+					n++
+				}
+			}
+			switch {
+			case n == 0 || n == 2:
+			case n == 3 && contains(l.r.unitlist[u], plus) && (digit == 0 || digit == d):
+				digit = d
+			default:
+				return nil
+			}
+		}
+	}
+	if digit == 0 {
+		return nil
+	}
+
+	st := l.begin(nil, []int{plus}, l.values[plus])
+	l.place(plus, digit)
+	return st
+}
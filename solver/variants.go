@@ -0,0 +1,142 @@
+package solver
+
+import "strings"
+
+// Variant names a kind of sudoku solved with constraints on top of the classic units.
+// Variants are combined by joining their names with a +, like "diagonal+anti-knight".
+type Variant string
+
+const (
+	// VariantClassic is the plain sudoku, it adds no constraint
+	VariantClassic Variant = "classic"
+	// VariantDiagonal is the Sudoku-X: both main diagonals hold each symbol once
+	VariantDiagonal Variant = "diagonal"
+	// VariantWindoku is the Hyper sudoku: the extra windows hold each symbol once
+	VariantWindoku Variant = "windoku"
+	// VariantAntiKnight forbids the squares a knight's move away to hold the same value
+	VariantAntiKnight Variant = "anti-knight"
+	// VariantAntiKing forbids the squares a king's move away to hold the same value
+	VariantAntiKing Variant = "anti-king"
+	// VariantNonConsecutive forbids the squares adjacent on a row or a column to hold consecutive values
+	VariantNonConsecutive Variant = "non-consecutive"
+)
+
+// variants holds the constraint of each variant, in the order they are listed in the errors
+var variants = []struct {
+	variant    Variant
+	constraint Constraint
+}{
+	{VariantClassic, nil},
+	{VariantDiagonal, Diagonal{}},
+	{VariantWindoku, Windoku{}},
+	{VariantAntiKnight, AntiKnight{}},
+	{VariantAntiKing, AntiKing{}},
+	{VariantNonConsecutive, NonConsecutive{}},
+}
+
+// Constraints returns the constraints of the variant, the empty variant being the classic one.
+// Return an *UnknownVariantError if one of the variants combined does not exist.
+func (v Variant) Constraints() ([]Constraint, error) {
+	if v == "" {
+		return nil, nil
+	}
+
+	var res []Constraint
+	for _, name := range strings.Split(string(v), "+") {
+		found := false
+		for _, vc := range variants {
+			if string(vc.variant) == name {
+				found = true
+				if vc.constraint != nil {
+					res = append(res, vc.constraint)
+				}
+			}
+		}
+		if !found {
+			return nil, &UnknownVariantError{Variant: string(v)}
+		}
+	}
+	return res, nil
+}
+
+// Diagonal is the constraint of the Sudoku-X: both main diagonals hold each symbol once
+type Diagonal struct {
+	NopConstraint
+}
+
+// Units returns the main diagonal and the anti-diagonal of each grid
+func (Diagonal) Units(g *Geometry) [][]int {
+	var res [][]int
+	for _, o := range g.grids {
+		diag, anti := make([]int, g.size), make([]int, g.size)
+		for i := 0; i < g.size; i++ {
+			// A1 B2 C3 D4 E5 F6 G7 H8 I9...
+			diag[i] = g.at(o[0]+i, o[1]+i)
+			// A9 B8 C7 D6 E5 F4 G3 H2 I1...
+			anti[i] = g.at(o[0]+i, o[1]+g.size-1-i)
+		}
+		res = append(res, diag, anti)
+	}
+	return res
+}
+
+// Windoku is the constraint of the Hyper sudoku: the windows, boxes laid out one square away from the
+// border of the grid and from each other, hold each symbol once. A classic sudoku has four windows.
+type Windoku struct {
+	NopConstraint
+}
+
+// Units returns the windows of each grid
+func (Windoku) Units(g *Geometry) [][]int {
+	var res [][]int
+	for _, o := range g.grids {
+		for top := 1; top+g.boxRows < g.size; top += g.boxRows + 1 {
+			for left := 1; left+g.boxCols < g.size; left += g.boxCols + 1 {
+				// B2 B3 B4 C2 C3 C4 D2 D3 D4...
+				window := make([]int, 0, g.size)
+				for r := top; r < top+g.boxRows; r++ {
+					for c := left; c < left+g.boxCols; c++ {
+						window = append(window, g.at(o[0]+r, o[1]+c))
+					}
+				}
+				res = append(res, window)
+			}
+		}
+	}
+	return res
+}
+
+// AntiKnight is the constraint of the anti-knight sudoku: squares a knight's move away hold different values
+type AntiKnight struct {
+	NopConstraint
+}
+
+// Peers returns the pairs of squares a knight's move away
+func (AntiKnight) Peers(g *Geometry) [][2]int {
+	return moves(g, [][2]int{{1, 2}, {2, 1}, {1, -2}, {2, -1}})
+}
+
+// AntiKing is the constraint of the anti-king sudoku: diagonally adjacent squares hold different values.
+// The squares adjacent on a row or a column are already peers.
+type AntiKing struct {
+	NopConstraint
+}
+
+// Peers returns the pairs of diagonally adjacent squares
+func (AntiKing) Peers(g *Geometry) [][2]int {
+	return moves(g, [][2]int{{1, 1}, {1, -1}})
+}
+
+// Moves list the pairs of squares of the board one of the moves away, given as a number of rows and of columns
+func moves(g *Geometry, offsets [][2]int) [][2]int {
+	var res [][2]int
+	for s := range g.squares {
+		r, c := g.position(s)
+		for _, o := range offsets {
+			if s2 := g.at(r+o[0], c+o[1]); s2 >= 0 {
+				res = append(res, [2]int{s, s2})
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+package solver_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/laurentlp/sudoku-solver/solver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const diagonalGrid = "9...48......2.........7..4............1....9..3.1.....36...1....5..6..8....7..5.."
+const diagonalSolution = "975648213648213975213975648796584132581327496432196857369851724157462389824739561"
+
+const antiKnightGrid = ".23......4.......................89...48..............3....5...6.....3....8.1..4."
+const antiKnightSolution = "123456789456789123789123456231564897564897231897231564312645978645978312978312645"
+
+const nonConsecutiveGrid = ".................................83..........9.............6..9.............3...."
+const nonConsecutiveSolution = "135247968792683514468159372624971835381524697957368241573816429816492753249735186"
+
+const windokuGrid = "9...4..........9......75.4............2....3..3.5......6.821.......6......4...7.."
+const windokuSolution = "975648213648213975213975648456732189792184536831596427567821394329467851184359762"
+
+func TestSudokuVariants(t *testing.T) {
+	Convey("Given variant sudokus and a solver", t, func() {
+		Convey("When SolveContext is called with a Sudoku-X", func() {
+			values, err := solver.SolveContext(context.Background(), diagonalGrid, solver.WithVariant(solver.VariantDiagonal))
+			unique, _ := solver.IsUnique(diagonalGrid, solver.WithVariant(solver.VariantDiagonal))
+			uniqueClassic, _ := solver.IsUnique(diagonalGrid)
+
+			Convey("Then show the solved sudoku, unique only with its diagonals", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values), ShouldEqual, diagonalSolution)
+				So(unique, ShouldBeTrue)
+				So(uniqueClassic, ShouldBeFalse)
+			})
+		})
+
+		Convey("When SolveContext is called with a Windoku", func() {
+			values, err := solver.SolveContext(context.Background(), windokuGrid, solver.WithVariant(solver.VariantWindoku))
+			unique, _ := solver.IsUnique(windokuGrid, solver.WithVariant(solver.VariantWindoku))
+
+			Convey("Then show the solved sudoku", func() {
+				So(err, ShouldBeNil)
+				So(solver.Classic.Flatten(values), ShouldEqual, windokuSolution)
+				So(unique, ShouldBeTrue)
+			})
+		})
+
+		Convey("When Solve is called with a Sudoku-X repeating a digit on a diagonal", func() {
+			_, err := solver.SolveContext(context.Background(), "1"+diagonalGrid[1:80]+"1", solver.WithVariant(solver.VariantDiagonal))
+
+			Convey("Then return a ConflictError with the conflicting cells", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+				So(conflictErr.Cells, ShouldResemble, []string{"A1", "I9"})
+			})
+		})
+
+		Convey("When SolveContext is called with an anti-knight and a non-consecutive sudoku", func() {
+			lenient := solver.WithValidation(solver.LenientValidation)
+			knight, errKnight := solver.SolveContext(context.Background(), antiKnightGrid, lenient, solver.WithVariant(solver.VariantAntiKnight))
+			consecutive, errConsecutive := solver.SolveContext(context.Background(), nonConsecutiveGrid, lenient, solver.WithVariant(solver.VariantNonConsecutive))
+			unique, _ := solver.IsUnique(nonConsecutiveGrid, lenient, solver.WithVariant(solver.VariantNonConsecutive))
+
+			Convey("Then show the solved sudokus", func() {
+				So(errKnight, ShouldBeNil)
+				So(solver.Classic.Flatten(knight), ShouldEqual, antiKnightSolution)
+				So(errConsecutive, ShouldBeNil)
+				So(solver.Classic.Flatten(consecutive), ShouldEqual, nonConsecutiveSolution)
+				So(unique, ShouldBeTrue)
+			})
+		})
+
+		Convey("When SolveContext is called with the anti-knight, anti-king and non-consecutive rules combined", func() {
+			variant := solver.VariantAntiKnight + "+" + solver.VariantAntiKing + "+" + solver.VariantNonConsecutive
+			values, err := solver.SolveContext(context.Background(), strings.Repeat(".", 81),
+				solver.WithValidation(solver.LenientValidation), solver.WithVariant(variant))
+
+			Convey("Then the solution follows every rule", func() {
+				So(err, ShouldBeNil)
+				sol := solver.Classic.Flatten(values)
+				at := func(r, c int) int {
+					if r < 0 || r > 8 || c < 0 || c > 8 {
+						return -10
+					}
+					return int(sol[r*9+c] - '0')
+				}
+				for r := 0; r < 9; r++ {
+					for c := 0; c < 9; c++ {
+						for _, m := range [][2]int{{1, 2}, {2, 1}, {1, -2}, {2, -1}, {1, 1}, {1, -1}} {
+							So(at(r+m[0], c+m[1]), ShouldNotEqual, at(r, c))
+						}
+						for _, m := range [][2]int{{0, 1}, {1, 0}} {
+							So(at(r+m[0], c+m[1])-at(r, c), ShouldNotBeIn, []int{-1, 1})
+						}
+					}
+				}
+			})
+		})
+
+		Convey("When Solve is called with clues a knight's move away repeating a digit", func() {
+			// A2 and C3 hold a 2
+			_, err := solver.SolveContext(context.Background(), antiKnightGrid[:20]+"2"+antiKnightGrid[21:],
+				solver.WithValidation(solver.LenientValidation), solver.WithVariant(solver.VariantAntiKnight))
+
+			Convey("Then return a ConflictError with the conflicting cells", func() {
+				var conflictErr *solver.ConflictError
+				So(errors.As(err, &conflictErr), ShouldBeTrue)
+				So(conflictErr.Cells, ShouldResemble, []string{"A2", "C3"})
+			})
+		})
+
+		Convey("When a Pairwise constraint has no relation", func() {
+			_, err := solver.CountSolutions(grid, 0, solver.WithConstraints(solver.Pairwise{Pairs: [][2]int{{0, 1}}}))
+
+			Convey("Then return an error", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the windows of a 4x4 sudoku are listed", func() {
+			g, _ := solver.NewGeometry(2, 2)
+			units := solver.Windoku{}.Units(g)
+
+			Convey("Then there is a single window in the middle", func() {
+				So(units, ShouldResemble, [][]int{{5, 6, 9, 10}})
+			})
+		})
+
+		Convey("When Solve is called with an unknown variant", func() {
+			_, err := solver.SolveContext(context.Background(), grid, solver.WithVariant("killer-x"))
+
+			Convey("Then return an UnknownVariantError", func() {
+				var variantErr *solver.UnknownVariantError
+				So(errors.As(err, &variantErr), ShouldBeTrue)
+				So(variantErr.Variant, ShouldEqual, "killer-x")
+			})
+		})
+	})
+}
@@ -0,0 +1,127 @@
+package solver
+
+import "math/bits"
+
+// XYWing finds a pivot with two candidates xy seeing two pincers xz and yz: whichever digit the pivot holds, one
+// of the pincers holds z, so z is eliminated from the squares seeing both pincers
+func (l *logic) xyWing() *Step {
+	for _, pivot := range l.bivalues() {
+		for _, a := range l.r.peers[pivot] {
+			for _, b := range l.r.peers[pivot] {
+				if a >= b || l.placed[a] || l.placed[b] || bits.OnesCount32(l.values[a]) != 2 || bits.OnesCount32(l.values[b]) != 2 {
+					continue
+				}
+				xy, az, bz := l.values[pivot], l.values[a], l.values[b]
+				z := az & bz
+				if bits.OnesCount32(z) != 1 || z&xy != 0 || az|bz != xy|z || az == bz {
+					continue
+				}
+				if st := l.wing(z, xy|z, []int{pivot, a, b}, []int{a, b}); st != nil {
+					return st
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// XYZWing finds a pivot with three candidates xyz seeing two pincers xz and yz: one of the three squares holds z,
+// so z is eliminated from the squares seeing all of them
+func (l *logic) xyzWing() *Step {
+	for pivot, xyz := range l.values {
+		if l.placed[pivot] || bits.OnesCount32(xyz) != 3 {
+			continue
+		}
+		for _, a := range l.r.peers[pivot] {
+			for _, b := range l.r.peers[pivot] {
+				if a >= b || l.placed[a] || l.placed[b] || bits.OnesCount32(l.values[a]) != 2 || bits.OnesCount32(l.values[b]) != 2 {
+					continue
+				}
+				az, bz := l.values[a], l.values[b]
+				z := az & bz
+				if bits.OnesCount32(z) != 1 || az|bz != xyz || az == bz {
+					continue
+				}
+				if st := l.wing(z, xyz, []int{pivot, a, b}, []int{pivot, a, b}); st != nil {
+					return st
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WWing finds two squares with the same two candidates xy which do not see each other, linked by a unit where x
+// can only go in two squares, each seeing one of them: one of the two squares holds y, so y is eliminated from
+// the squares seeing both
+func (l *logic) wWing() *Step {
+	bv := l.bivalues()
+	for i, a := range bv {
+		for _, b := range bv[i+1:] {
+			if l.values[a] != l.values[b] || contains(l.r.peers[a], b) {
+				continue
+			}
+
+			for x := l.values[a]; x != 0; x &= x - 1 {
+				dx := x & -x
+				y := l.values[a] &^ dx
+				for u, unit := range l.r.unitlist {
+					var link []int
+					placed := false
+					for _, s := range unit {
+						if l.values[s]&dx != 0 {
+							link = append(link, s)
+							placed = placed || l.placed[s]
+						}
+					}
+					if placed || len(link) != 2 || contains(link, a) || contains(link, b) {
+						continue
+					}
+					if !l.seesAll(a, link[:1]) || !l.seesAll(b, link[1:]) {
+						if !l.seesAll(a, link[1:]) || !l.seesAll(b, link[:1]) {
+							continue
+						}
+						link[0], link[1] = link[1], link[0]
+					}
+
+					if st := l.wing(y, l.values[a], []int{a, link[0], link[1], b}, []int{a, b}); st != nil {
+						st.Units = []string{l.names[u]}
+						return st
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Wing eliminates digit z from the squares seeing all the squares of seen, recording a step based on the squares
+// and their digits. Return nil if z can not be eliminated anywhere.
+func (l *logic) wing(z, digits uint32, squares, seen []int) *Step {
+	var targets []int
+	for _, s := range l.r.peers[seen[0]] {
+		if !l.placed[s] && l.values[s]&z != 0 && !contains(squares, s) && l.seesAll(s, seen[1:]) {
+			targets = append(targets, s)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	st := l.begin(nil, squares, digits)
+	for _, s := range targets {
+		l.eliminate(s, z)
+	}
+	return st
+}
+
+// Bivalues returns the squares left with two candidates
+func (l *logic) bivalues() []int {
+	var res []int
+	for s, v := range l.values {
+		if !l.placed[s] && bits.OnesCount32(v) == 2 {
+			res = append(res, s)
+		}
+	}
+	return res
+}